@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"packages/RSA"
+)
+
+/* Run the command given on the command line */
+func runCommand(command string, args []string) {
+	switch command {
+	case "keyinfo":
+		requireArgs(command, args, 1, "<key file> [pkcs1|pkcs8|spki]")
+		keyInfo(args)
+	case "keystore":
+		runKeystoreCommand(args)
+	case "wallet":
+		runWalletCommand(args)
+	case "shamir":
+		runShamirCommand(args)
+	case "voucher":
+		runVoucherCommand(args)
+	case "tx":
+		runTransactionCommand(args)
+	case "signer":
+		runSignerCommand(args)
+	default:
+		fmt.Println("Unknown command " + command + ". Available commands: keyinfo, keystore, wallet, shamir, voucher, tx, signer")
+		os.Exit(1)
+	}
+}
+
+/* Exit with a usage message if a command is given too few arguments */
+func requireArgs(command string, args []string, count int, usage string) {
+	if len(args) < count {
+		fmt.Println("Usage: " + command + " " + usage)
+		os.Exit(1)
+	}
+}
+
+/* Print the fingerprint of a key file, and convert it to another format if one is given */
+func keyInfo(args []string) {
+	keyBytes, err := ioutil.ReadFile(args[0])
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	key, err := RSA.ParseKey(string(keyBytes))
+	if err != nil {
+		fmt.Println("Could not parse key: " + err.Error())
+		os.Exit(1)
+	}
+	fingerprint, err := RSA.Fingerprint(key)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	kind := "public"
+	if key.IsPrivate() {
+		kind = "private"
+	}
+	fmt.Printf("RSA %v key, %v bits, fingerprint %v\n", kind, key.N.BitLen(), fingerprint)
+	if len(args) < 2 {
+		return
+	}
+	var encoded string
+	if key.IsPrivate() && args[1] != RSA.FORMAT_SPKI {
+		encoded, err = RSA.EncodePrivateKeyPEM(key, args[1])
+	} else {
+		encoded, err = RSA.EncodePublicKeyPEM(key.PublicKey(), args[1])
+	}
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	fmt.Print(encoded)
+}
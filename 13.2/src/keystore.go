@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"packages/address"
+	"packages/keystore"
+	"packages/peer"
+	"packages/signature"
+)
+
+/* Run a keystore command: new, list, pubkey or passwd */
+func runKeystoreCommand(args []string) {
+	requireArgs("keystore", args, 2, "new <dir> <name> [rsa|ed25519] | list <dir> | pubkey <dir> <name> <file> | passwd <dir> <name>")
+	store, err := keystore.Open(args[1])
+	exitOnError(err)
+	switch args[0] {
+	case "new":
+		requireArgs("keystore new", args[1:], 2, "<dir> <name> [rsa|ed25519]")
+		keyType := signature.DEFAULT_KEY_TYPE
+		if len(args) > 3 {
+			keyType = args[3]
+		}
+		signer, err := signature.GenerateSigner(keyType)
+		exitOnError(err)
+		password := readNewPassword()
+		exitOnError(store.Store(args[2], password, signer.PrivateKey(), signer.PublicKey()))
+		fmt.Println("Stored " + keyType + " key " + args[2] + " for account " + address.FromPublicKey(signer.PublicKey()))
+	case "list":
+		names, err := store.List()
+		exitOnError(err)
+		for _, name := range names {
+			publicKey, err := store.PublicKey(name)
+			if err != nil {
+				fmt.Println(name + ": " + err.Error())
+				continue
+			}
+			fmt.Println(name + ": " + signature.KeyType(publicKey) + " account " + address.FromPublicKey(publicKey))
+		}
+	case "pubkey":
+		requireArgs("keystore pubkey", args[1:], 3, "<dir> <name> <file>")
+		publicKey, err := store.PublicKey(args[2])
+		exitOnError(err)
+		exitOnError(ioutil.WriteFile(args[3], []byte(publicKey), 0644))
+		fmt.Println("Public key of " + args[2] + " written to " + args[3])
+	case "passwd":
+		requireArgs("keystore passwd", args[1:], 2, "<dir> <name>")
+		fmt.Println("Current password:")
+		oldPassword := readPassword()
+		newPassword := readNewPassword()
+		exitOnError(store.ChangePassword(args[2], oldPassword, newPassword))
+		fmt.Println("Password of key " + args[2] + " changed.")
+	default:
+		fmt.Println("Unknown keystore command " + args[0])
+		os.Exit(1)
+	}
+}
+
+/* Read a password from the user */
+func readPassword() string {
+	return peer.ReadLine()
+}
+
+/* Read a new password twice from the user */
+func readNewPassword() string {
+	fmt.Println("New password:")
+	password := readPassword()
+	fmt.Println("Repeat new password:")
+	if readPassword() != password {
+		fmt.Println("Passwords do not match.")
+		os.Exit(1)
+	}
+	return password
+}
+
+/* Print an error and exit, if there is one */
+func exitOnError(err error) {
+	if err != nil {
+		fmt.Println("Error: " + err.Error())
+		os.Exit(1)
+	}
+}
@@ -8,10 +8,16 @@ DESCRIPTION: Distributed transaction system implemented as structured P2P floodi
 package main
 
 import (
+	"os"
 	"packages/peer"
 )
 
 func main() {
+	/* Run a command instead of the peer, if one is given */
+	if len(os.Args) > 1 {
+		runCommand(os.Args[1], os.Args[2:])
+		return
+	}
 	var p = peer.Peer{}
 	p.StartPeer()
 	for true {
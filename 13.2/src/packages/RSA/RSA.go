@@ -16,15 +16,22 @@ import (
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"hash"
 	"math/big"
 )
 
-/* Key struct */
+/* Key struct. Private keys also hold the public exponent and the CRT values used for fast signing */
 type Key struct {
 	N      *big.Int
 	E_or_d *big.Int
+	E      *big.Int `json:",omitempty"` // Public exponent
+	P      *big.Int `json:",omitempty"` // First prime factor of N
+	Q      *big.Int `json:",omitempty"` // Second prime factor of N
+	Dp     *big.Int `json:",omitempty"` // d mod (p-1)
+	Dq     *big.Int `json:",omitempty"` // d mod (q-1)
+	Qinv   *big.Int `json:",omitempty"` // q^-1 mod p
 }
 
 /* Encode key to string */
@@ -36,10 +43,9 @@ func (key *Key) ToString() string {
 	return string(keyString)
 }
 
-/* Decode string to key */
+/* Decode string to key, returning an empty key if the string is invalid. Use ParseKey to get the error */
 func ToKey(keyString string) Key {
-	var key Key
-	json.Unmarshal([]byte(keyString), &key)
+	key, _ := ParseKey(keyString)
 	return key
 }
 
@@ -54,48 +60,40 @@ func GenerateRandomK() *big.Int {
 	return k
 }
 
-/* Key generator method */
+/* Key generator method, generating a key with the bit-length of k. Use GenerateKey to handle errors */
 func KeyGen(K *big.Int, e int) (Key, Key) {
-	/* Convert constants 1, E and K to big ints */
-	ONE := big.NewInt(1)
-	E := big.NewInt(int64(e))
-
-	/* Determine bitlength of k */
-	bitLength := K.BitLen()
-
-	/* Step 1: Generate prime, p, with half the bitlength of k.
-	   - The reason is that the product of two numbers with bitlength n/2 is n */
-	p, _ := rand.Prime(rand.Reader, bitLength/2)
-
-	/* Step 2: Subtract 1 from p */
-	P := new(big.Int).Sub(p, ONE)
+	publicKey, privateKey, err := GenerateKey(K.BitLen(), e)
+	if err != nil {
+		panic(err)
+	}
+	return publicKey, privateKey
+}
 
-	/* Step 3: Find GCD between E and P */
-	gcd_1 := new(big.Int).GCD(nil, nil, E, P)
+/* Check if a private key holds the values needed for signing with the CRT */
+func (key *Key) HasCRT() bool {
+	return key.P != nil && key.Q != nil && key.Dp != nil && key.Dq != nil && key.Qinv != nil
+}
 
-	/* For GCD != 1, repeat steps 1, 2 and 3 */
-	for ONE.Cmp(gcd_1) != 0 {
-		p, _ = rand.Prime(rand.Reader, bitLength/2)
-		P = new(big.Int).Sub(p, ONE)
-		gcd_1 = new(big.Int).GCD(nil, nil, E, P)
+/* Raise M to the private exponent, using the Chinese Remainder Theorem when the factors of N are known */
+func SignRaw(M *big.Int, privateKey Key) (*big.Int, error) {
+	if !privateKey.HasCRT() {
+		return Encrypt(M, privateKey), nil
 	}
-
-	/* Generate prime q applying same procedure as explained for p */
-	q, _ := rand.Prime(rand.Reader, bitLength/2)
-	Q := new(big.Int).Sub(q, ONE)
-	gcd_2 := new(big.Int).GCD(nil, nil, E, Q)
-	for ONE.Cmp(gcd_2) != 0 && p.Cmp(q) != 0 {
-		q, _ = rand.Prime(rand.Reader, bitLength/2)
-		Q = new(big.Int).Sub(q, ONE)
-		gcd_2 = new(big.Int).GCD(nil, nil, E, Q)
+	/* s1 = M^dP mod p, s2 = M^dQ mod q */
+	s1 := new(big.Int).Exp(M, privateKey.Dp, privateKey.P)
+	s2 := new(big.Int).Exp(M, privateKey.Dq, privateKey.Q)
+
+	/* h = qInv * (s1 - s2) mod p, s = s2 + h * q */
+	h := new(big.Int).Sub(s1, s2)
+	h.Mul(h, privateKey.Qinv).Mod(h, privateKey.P)
+	s := new(big.Int).Mul(h, privateKey.Q)
+	s.Add(s, s2)
+
+	/* Fault check: verify the result with the public exponent, so that a faulty computation cannot leak the factors */
+	if privateKey.E != nil && new(big.Int).Exp(s, privateKey.E, privateKey.N).Cmp(new(big.Int).Mod(M, privateKey.N)) != 0 {
+		return nil, errors.New("RSA signature failed verification")
 	}
-
-	/* Generate public key as (n, e) */
-	publicKey := Key{N: new(big.Int).Mul(p, q), E_or_d: E}
-
-	/* Generate private key as (n, d) */
-	privateKey := Key{N: new(big.Int).Mul(p, q), E_or_d: new(big.Int).ModInverse(E, new(big.Int).Mul(P, Q))}
-	return publicKey, privateKey
+	return s, nil
 }
 
 /* Encrypt method */
@@ -140,7 +138,10 @@ func GenerateSignature(templateObject interface{}, privateKeyString string) stri
 	privateKey := ToKey(privateKeyString)
 
 	/* Encrypt the hashed transaction with the private key */
-	ciphertext := Encrypt(objectHash, privateKey)
+	ciphertext, err := SignRaw(objectHash, privateKey)
+	if err != nil {
+		panic(err)
+	}
 
 	/* Pad ciphertext with zeros */
 	ciphertextInBytes := ciphertext.Bytes()
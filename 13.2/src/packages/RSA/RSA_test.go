@@ -0,0 +1,61 @@
+package RSA
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+/* Signing with the CRT must give the same result as raising to the full private exponent */
+func TestSignRawCRT(t *testing.T) {
+	_, privateKey, err := GenerateKey(2048, DEFAULT_E)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plainKey := Key{N: privateKey.N, E_or_d: privateKey.E_or_d}
+	for i := 0; i < 10; i++ {
+		message, _ := rand.Int(rand.Reader, privateKey.N)
+		plain, err := SignRaw(message, plainKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		crt, err := SignRaw(message, privateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if plain.Cmp(crt) != 0 {
+			t.Fatal("CRT signature differs from the signature with the full private exponent")
+		}
+	}
+}
+
+/* A faulty CRT value must be caught by the fault check instead of producing a signature */
+func TestSignRawFaultCheck(t *testing.T) {
+	_, privateKey, err := GenerateKey(2048, DEFAULT_E)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privateKey.Dp = new(big.Int).Add(privateKey.Dp, big.NewInt(1))
+	if _, err := SignRaw(big.NewInt(42), privateKey); err == nil {
+		t.Fatal("faulty CRT signature was returned")
+	}
+}
+
+func benchmarkSignRaw(b *testing.B, crt bool) {
+	_, privateKey, err := GenerateKey(2048, DEFAULT_E)
+	if err != nil {
+		b.Fatal(err)
+	}
+	if !crt {
+		privateKey = Key{N: privateKey.N, E_or_d: privateKey.E_or_d}
+	}
+	message, _ := rand.Int(rand.Reader, privateKey.N)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		SignRaw(message, privateKey)
+	}
+}
+
+/* Compare RSA signing with the full private exponent and with the CRT, including its fault check */
+func BenchmarkSignRawWithoutCRT(b *testing.B) { benchmarkSignRaw(b, false) }
+func BenchmarkSignRawWithCRT(b *testing.B)    { benchmarkSignRaw(b, true) }
@@ -0,0 +1,74 @@
+/**
+The requester blinds the full-domain hash m of a message with a random r as m r^e mod N,
+the signer raises it to d, and the requester divides the result by r to get m^d mod N,
+so the signer never sees m or the signature. A blind signing key signs anything it is
+given, so it must never be used for other signatures.
+**/
+
+package RSA
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+)
+
+const blindFDHDomainSeparator = 0x03
+
+/* Blind the full-domain hash of a message, returning the blinded message and the blinding factor */
+func Blind(publicKey Key, message []byte) (*big.Int, *big.Int, error) {
+	if publicKey.N == nil || publicKey.E_or_d == nil {
+		return nil, nil, errors.New("invalid RSA public key")
+	}
+	for {
+		r, err := rand.Int(rand.Reader, publicKey.N)
+		if err != nil {
+			return nil, nil, err
+		}
+		// r must be invertible modulo N to unblind
+		if r.Sign() == 0 || new(big.Int).GCD(nil, nil, r, publicKey.N).Cmp(big.NewInt(1)) != 0 {
+			continue
+		}
+		blinded := new(big.Int).Mul(FullDomainHash(publicKey.N, message), Decrypt(r, publicKey))
+		return blinded.Mod(blinded, publicKey.N), r, nil
+	}
+}
+
+/* Sign a blinded message with a blind signing key */
+func SignBlinded(blinded *big.Int, privateKey Key) (*big.Int, error) {
+	if privateKey.N == nil || blinded.Sign() <= 0 || blinded.Cmp(privateKey.N) >= 0 {
+		return nil, errors.New("blinded message out of range")
+	}
+	return SignRaw(blinded, privateKey)
+}
+
+/* Remove the blinding factor from a blind signature, checking that the result is a valid signature of the message */
+func Unblind(publicKey Key, message []byte, blindSignature *big.Int, r *big.Int) (*big.Int, error) {
+	rInverse := new(big.Int).ModInverse(r, publicKey.N)
+	if rInverse == nil {
+		return nil, errors.New("blinding factor is not invertible")
+	}
+	signature := new(big.Int).Mul(blindSignature, rInverse)
+	signature.Mod(signature, publicKey.N)
+	if !VerifyFDH(publicKey, message, signature) {
+		return nil, errors.New("blind signature is invalid")
+	}
+	return signature, nil
+}
+
+/* Verify a full-domain-hash signature of a message, as produced by unblinding */
+func VerifyFDH(publicKey Key, message []byte, signature *big.Int) bool {
+	if publicKey.N == nil || publicKey.E_or_d == nil || signature.Sign() <= 0 || signature.Cmp(publicKey.N) >= 0 {
+		return false
+	}
+	return Decrypt(signature, publicKey).Cmp(FullDomainHash(publicKey.N, message)) == 0
+}
+
+/* Full-domain hash of a message: MGF1-SHA256 output one byte shorter than the modulus */
+func FullDomainHash(N *big.Int, message []byte) *big.Int {
+	k := (N.BitLen() + 7) / 8
+	nBytes, _ := i2osp(N, k)
+	seed := append([]byte{blindFDHDomainSeparator}, nBytes...)
+	seed = append(seed, message...)
+	return new(big.Int).SetBytes(mgf1(seed, k-1))
+}
@@ -0,0 +1,37 @@
+package RSA
+
+import "testing"
+
+/* Unblinded signatures must verify, while the signer only sees a blinded message */
+func TestBlindSignature(t *testing.T) {
+	publicKey, privateKey, err := GenerateKey(1024, DEFAULT_E)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := []byte("voucher")
+	blinded, r, err := Blind(publicKey, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blinded2, _, err := Blind(publicKey, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if blinded.Cmp(blinded2) == 0 || blinded.Cmp(FullDomainHash(publicKey.N, message)) == 0 {
+		t.Error("blinded messages are linkable")
+	}
+	blindSignature, err := SignBlinded(blinded, privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err := Unblind(publicKey, message, blindSignature, r)
+	if err != nil || !VerifyFDH(publicKey, message, signature) {
+		t.Fatal("unblinded signature does not verify")
+	}
+	if VerifyFDH(publicKey, []byte("other voucher"), signature) {
+		t.Error("unblinded signature accepted for another message")
+	}
+	if _, err := Unblind(publicKey, message, blindSignature, blinded2); err == nil {
+		t.Error("wrong blinding factor accepted")
+	}
+}
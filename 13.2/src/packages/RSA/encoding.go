@@ -0,0 +1,199 @@
+package RSA
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"strings"
+)
+
+/* Key encoding formats */
+const FORMAT_PKCS1 = "pkcs1" // "RSA PUBLIC KEY" and "RSA PRIVATE KEY" blocks
+const FORMAT_PKCS8 = "pkcs8" // "PRIVATE KEY" blocks
+const FORMAT_SPKI = "spki"   // "PUBLIC KEY" blocks
+
+/* Public exponents fit in an int, so a larger E_or_d is the private exponent of a legacy JSON key holding only N and d */
+const MAX_PUBLIC_EXPONENT_BITS = 64
+
+/* Check if a key is a private key */
+func (key *Key) IsPrivate() bool {
+	return key.E != nil || key.P != nil || (key.E_or_d != nil && key.E_or_d.BitLen() > MAX_PUBLIC_EXPONENT_BITS)
+}
+
+/* Convert a public key to the standard library representation */
+func (key *Key) ToRSAPublicKey() (*rsa.PublicKey, error) {
+	E := key.E_or_d
+	if key.IsPrivate() {
+		E = key.E
+		if E == nil {
+			return nil, errors.New("legacy RSA private key does not hold its public exponent")
+		}
+	}
+	if key.N == nil || E == nil || !E.IsInt64() {
+		return nil, errors.New("invalid RSA public key")
+	}
+	return &rsa.PublicKey{N: key.N, E: int(E.Int64())}, nil
+}
+
+/* Convert a private key to the standard library representation. The key must hold its prime factors */
+func (key *Key) ToRSAPrivateKey() (*rsa.PrivateKey, error) {
+	if !key.HasCRT() || key.E == nil {
+		return nil, errors.New("RSA private key does not hold its prime factors")
+	}
+	publicKey, err := key.ToRSAPublicKey()
+	if err != nil {
+		return nil, err
+	}
+	privateKey := &rsa.PrivateKey{PublicKey: *publicKey, D: key.E_or_d, Primes: []*big.Int{key.P, key.Q}}
+	if err := privateKey.Validate(); err != nil {
+		return nil, err
+	}
+	privateKey.Precompute()
+	return privateKey, nil
+}
+
+/* Convert a public key from the standard library representation */
+func FromRSAPublicKey(publicKey *rsa.PublicKey) Key {
+	return Key{N: publicKey.N, E_or_d: big.NewInt(int64(publicKey.E))}
+}
+
+/* Convert a private key from the standard library representation */
+func FromRSAPrivateKey(privateKey *rsa.PrivateKey) (Key, error) {
+	if len(privateKey.Primes) != 2 {
+		return Key{}, errors.New("multi-prime RSA keys are not supported")
+	}
+	privateKey.Precompute()
+	E := big.NewInt(int64(privateKey.E))
+	key := Key{N: privateKey.N, E_or_d: privateKey.D, E: E, P: privateKey.Primes[0], Q: privateKey.Primes[1]}
+	key.Dp = new(big.Int).Mod(privateKey.D, new(big.Int).Sub(key.P, big.NewInt(1)))
+	key.Dq = new(big.Int).Mod(privateKey.D, new(big.Int).Sub(key.Q, big.NewInt(1)))
+	key.Qinv = new(big.Int).ModInverse(key.Q, key.P)
+	return key, nil
+}
+
+/* Get the public key belonging to a private key */
+func (key *Key) PublicKey() Key {
+	if !key.IsPrivate() {
+		return *key
+	}
+	return Key{N: key.N, E_or_d: key.E}
+}
+
+/* Encode a public key as PEM, in the PKCS#1 or SubjectPublicKeyInfo format */
+func EncodePublicKeyPEM(key Key, format string) (string, error) {
+	publicKey, err := key.ToRSAPublicKey()
+	if err != nil {
+		return "", err
+	}
+	var block *pem.Block
+	switch format {
+	case FORMAT_PKCS1:
+		block = &pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(publicKey)}
+	case FORMAT_SPKI, "":
+		der, err := x509.MarshalPKIXPublicKey(publicKey)
+		if err != nil {
+			return "", err
+		}
+		block = &pem.Block{Type: "PUBLIC KEY", Bytes: der}
+	default:
+		return "", errors.New("unknown public key format " + format)
+	}
+	return string(pem.EncodeToMemory(block)), nil
+}
+
+/* Encode a private key as PEM, in the PKCS#1 or PKCS#8 format */
+func EncodePrivateKeyPEM(key Key, format string) (string, error) {
+	privateKey, err := key.ToRSAPrivateKey()
+	if err != nil {
+		return "", err
+	}
+	var block *pem.Block
+	switch format {
+	case FORMAT_PKCS1:
+		block = &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)}
+	case FORMAT_PKCS8, "":
+		der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+		if err != nil {
+			return "", err
+		}
+		block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	default:
+		return "", errors.New("unknown private key format " + format)
+	}
+	return string(pem.EncodeToMemory(block)), nil
+}
+
+/* Parse a public or private key in PEM (PKCS#1, PKCS#8 or SubjectPublicKeyInfo) or in the legacy JSON format */
+func ParseKey(keyString string) (Key, error) {
+	trimmed := strings.TrimSpace(keyString)
+	if !strings.HasPrefix(trimmed, "-----BEGIN") {
+		var key Key
+		if err := json.Unmarshal([]byte(trimmed), &key); err != nil {
+			return Key{}, err
+		}
+		if key.N == nil || key.E_or_d == nil {
+			return Key{}, errors.New("RSA key is missing N or E_or_d")
+		}
+		return key, nil
+	}
+
+	block, _ := pem.Decode([]byte(trimmed))
+	if block == nil {
+		return Key{}, errors.New("invalid PEM data")
+	}
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		publicKey, err := x509.ParsePKCS1PublicKey(block.Bytes)
+		if err != nil {
+			return Key{}, err
+		}
+		return FromRSAPublicKey(publicKey), nil
+	case "PUBLIC KEY":
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return Key{}, err
+		}
+		publicKey, ok := parsed.(*rsa.PublicKey)
+		if !ok {
+			return Key{}, errors.New("public key is not an RSA key")
+		}
+		return FromRSAPublicKey(publicKey), nil
+	case "RSA PRIVATE KEY":
+		privateKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return Key{}, err
+		}
+		return FromRSAPrivateKey(privateKey)
+	case "PRIVATE KEY":
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return Key{}, err
+		}
+		privateKey, ok := parsed.(*rsa.PrivateKey)
+		if !ok {
+			return Key{}, errors.New("private key is not an RSA key")
+		}
+		return FromRSAPrivateKey(privateKey)
+	default:
+		return Key{}, errors.New("unsupported PEM block " + block.Type)
+	}
+}
+
+/* Fingerprint of a key for display, the SHA-256 hash of its SubjectPublicKeyInfo encoding */
+func Fingerprint(key Key) (string, error) {
+	publicKey, err := key.ToRSAPublicKey()
+	if err != nil {
+		return "", err
+	}
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(der)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(hash[:]), nil
+}
@@ -0,0 +1,28 @@
+package RSA
+
+import "testing"
+
+/* A legacy JSON private key holding only N and d must not be taken for a public key */
+func TestIsPrivateLegacyKey(t *testing.T) {
+	publicKey, privateKey, err := GenerateKey(1024, DEFAULT_E)
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy := Key{N: privateKey.N, E_or_d: privateKey.E_or_d}
+	parsed, err := ParseKey(legacy.ToString())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !parsed.IsPrivate() {
+		t.Error("legacy private key reported as public")
+	}
+	if publicKey.IsPrivate() {
+		t.Error("public key reported as private")
+	}
+	if _, err := parsed.ToRSAPublicKey(); err == nil {
+		t.Error("public key derived from the private exponent of a legacy key")
+	}
+	if _, err := Fingerprint(parsed); err == nil {
+		t.Error("fingerprint computed from the private exponent of a legacy key")
+	}
+}
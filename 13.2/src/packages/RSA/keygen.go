@@ -0,0 +1,149 @@
+package RSA
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+	"math/big"
+)
+
+const MIN_KEY_BITS = 1024
+const MAX_KEY_GENERATION_ATTEMPTS = 100
+const PRIMALITY_ROUNDS = 20
+
+/* Generate a key pair with a modulus of exactly the given number of bits and the given public exponent */
+func GenerateKey(bits int, e int) (Key, Key, error) {
+	return GenerateKeyFromReader(rand.Reader, bits, e)
+}
+
+/* Generate a key pair, reading randomness from the given reader. A deterministic reader gives a deterministic key */
+func GenerateKeyFromReader(random io.Reader, bits int, e int) (Key, Key, error) {
+	if bits < MIN_KEY_BITS {
+		return Key{}, Key{}, errors.New("RSA modulus must have at least 1024 bits")
+	}
+	if e < 3 || e%2 == 0 {
+		return Key{}, Key{}, errors.New("RSA public exponent must be odd and at least 3")
+	}
+	ONE := big.NewInt(1)
+	E := big.NewInt(int64(e))
+
+	for attempt := 0; attempt < MAX_KEY_GENERATION_ATTEMPTS; attempt++ {
+		/* The primes get the top two bits set, so the product of a (bits+1)/2 and a bits/2 bit prime has exactly bits bits */
+		p, err := generatePrime(random, (bits+1)/2)
+		if err != nil {
+			return Key{}, Key{}, err
+		}
+		q, err := generatePrime(random, bits/2)
+		if err != nil {
+			return Key{}, Key{}, err
+		}
+		if p.Cmp(q) == 0 {
+			continue
+		}
+		N := new(big.Int).Mul(p, q)
+		if N.BitLen() != bits {
+			continue
+		}
+
+		/* gcd(e, λ(N)) = 1, where λ(N) = lcm(p-1, q-1) */
+		P := new(big.Int).Sub(p, ONE)
+		Q := new(big.Int).Sub(q, ONE)
+		lambda := new(big.Int).Mul(P, Q)
+		lambda.Div(lambda, new(big.Int).GCD(nil, nil, P, Q))
+		if new(big.Int).GCD(nil, nil, E, lambda).Cmp(ONE) != 0 {
+			continue
+		}
+
+		/* Keep p > q, as usual for the CRT values */
+		if p.Cmp(q) < 0 {
+			p, q = q, p
+			P, Q = Q, P
+		}
+		d := new(big.Int).ModInverse(E, lambda)
+		publicKey := Key{N: N, E_or_d: E}
+		privateKey := Key{N: new(big.Int).Set(N), E_or_d: d, E: E, P: p, Q: q}
+		privateKey.Dp = new(big.Int).Mod(d, P)
+		privateKey.Dq = new(big.Int).Mod(d, Q)
+		privateKey.Qinv = new(big.Int).ModInverse(q, p)
+		return publicKey, privateKey, nil
+	}
+	return Key{}, Key{}, errors.New("RSA key generation failed to find suitable primes")
+}
+
+/* Generate a prime of exactly the given number of bits with the top two bits set */
+func generatePrime(random io.Reader, bits int) (*big.Int, error) {
+	if bits < 16 {
+		return nil, errors.New("prime size too small")
+	}
+	bytes := make([]byte, (bits+7)/8)
+	for {
+		if _, err := io.ReadFull(random, bytes); err != nil {
+			return nil, err
+		}
+		/* Clear the bits above the bit-length, then set the top two bits and make the candidate odd */
+		excess := uint(len(bytes)*8 - bits)
+		bytes[0] &= byte(0xff >> excess)
+		candidate := new(big.Int).SetBytes(bytes)
+		candidate.SetBit(candidate, bits-1, 1)
+		candidate.SetBit(candidate, bits-2, 1)
+		candidate.SetBit(candidate, 0, 1)
+
+		/* Search upwards from the candidate, while it keeps its bit-length */
+		for candidate.BitLen() == bits {
+			if candidate.ProbablyPrime(PRIMALITY_ROUNDS) {
+				return candidate, nil
+			}
+			candidate.Add(candidate, big.NewInt(2))
+		}
+	}
+}
+
+/* Check the properties a generated key pair must have, returning the first one that does not hold */
+func ValidateKeyPair(publicKey Key, privateKey Key, bits int) error {
+	ONE := big.NewInt(1)
+	if publicKey.N == nil || publicKey.E_or_d == nil || privateKey.E_or_d == nil || !privateKey.HasCRT() {
+		return errors.New("key is incomplete")
+	}
+	if publicKey.N.BitLen() != bits {
+		return errors.New("modulus does not have the requested size")
+	}
+	if publicKey.N.Cmp(privateKey.N) != 0 || new(big.Int).Mul(privateKey.P, privateKey.Q).Cmp(publicKey.N) != 0 {
+		return errors.New("modulus is not the product of the primes")
+	}
+	if privateKey.P.Cmp(privateKey.Q) == 0 {
+		return errors.New("primes are not distinct")
+	}
+	if !privateKey.P.ProbablyPrime(PRIMALITY_ROUNDS) || !privateKey.Q.ProbablyPrime(PRIMALITY_ROUNDS) {
+		return errors.New("factors are not prime")
+	}
+	P := new(big.Int).Sub(privateKey.P, ONE)
+	Q := new(big.Int).Sub(privateKey.Q, ONE)
+	lambda := new(big.Int).Mul(P, Q)
+	lambda.Div(lambda, new(big.Int).GCD(nil, nil, P, Q))
+	if new(big.Int).GCD(nil, nil, publicKey.E_or_d, lambda).Cmp(ONE) != 0 {
+		return errors.New("public exponent is not coprime with λ(N)")
+	}
+	ed := new(big.Int).Mul(publicKey.E_or_d, privateKey.E_or_d)
+	if ed.Mod(ed, lambda).Cmp(ONE) != 0 {
+		return errors.New("private exponent is not the inverse of the public exponent")
+	}
+	if new(big.Int).Mod(privateKey.E_or_d, P).Cmp(privateKey.Dp) != 0 || new(big.Int).Mod(privateKey.E_or_d, Q).Cmp(privateKey.Dq) != 0 {
+		return errors.New("CRT exponents do not match the private exponent")
+	}
+	qQinv := new(big.Int).Mul(privateKey.Q, privateKey.Qinv)
+	if qQinv.Mod(qQinv, privateKey.P).Cmp(ONE) != 0 {
+		return errors.New("CRT coefficient is not the inverse of q")
+	}
+	message, err := rand.Int(rand.Reader, publicKey.N)
+	if err != nil {
+		return err
+	}
+	signature, err := SignRaw(message, privateKey)
+	if err != nil {
+		return err
+	}
+	if Decrypt(signature, publicKey).Cmp(message) != 0 {
+		return errors.New("signature does not verify")
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package RSA
+
+import (
+	"crypto/sha256"
+	"strconv"
+	"testing"
+)
+
+const KEY_GENERATION_CHECKS = 5
+
+/* Generated keys must have the properties of ValidateKeyPair for several sizes and exponents */
+func TestGenerateKey(t *testing.T) {
+	for _, bits := range []int{1024, 1025, 2048} {
+		for _, e := range []int{3, 65537} {
+			for i := 0; i < KEY_GENERATION_CHECKS; i++ {
+				publicKey, privateKey, err := GenerateKey(bits, e)
+				if err == nil {
+					err = ValidateKeyPair(publicKey, privateKey, bits)
+				}
+				if err != nil {
+					t.Errorf("key generation (%v bits, e = %v): %v", bits, e, err)
+				}
+			}
+		}
+	}
+}
+
+/* Invalid parameters must be reported instead of producing weak keys */
+func TestGenerateKeyInvalidParameters(t *testing.T) {
+	for _, parameters := range [][2]int{{512, 65537}, {2048, 1}, {2048, 4}, {2048, 65536}} {
+		if _, _, err := GenerateKey(parameters[0], parameters[1]); err == nil {
+			t.Errorf("key generation (%v bits, e = %v) did not return an error", parameters[0], parameters[1])
+		}
+	}
+}
+
+/* The same randomness must give the same key */
+func TestGenerateKeyFromReader(t *testing.T) {
+	first, _, err := GenerateKeyFromReader(newCounterReader("seed"), 1024, DEFAULT_E)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _, err := GenerateKeyFromReader(newCounterReader("seed"), 1024, DEFAULT_E)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.N.Cmp(second.N) != 0 {
+		t.Fatal("key generation from the same randomness gave different keys")
+	}
+}
+
+/* Deterministic reader, producing SHA-256(seed || counter) blocks */
+type counterReader struct {
+	seed    string
+	counter int
+	buffer  []byte
+}
+
+func newCounterReader(seed string) *counterReader {
+	return &counterReader{seed: seed}
+}
+
+func (reader *counterReader) Read(p []byte) (int, error) {
+	for len(reader.buffer) < len(p) {
+		block := sha256.Sum256([]byte(reader.seed + strconv.Itoa(reader.counter)))
+		reader.buffer = append(reader.buffer, block[:]...)
+		reader.counter++
+	}
+	n := copy(p, reader.buffer)
+	reader.buffer = reader.buffer[n:]
+	return n, nil
+}
@@ -0,0 +1,199 @@
+/**
+The encodings follow RFC 8017 (PKCS #1 v2.2), sections 9.1 (EMSA-PSS) and 9.2 (EMSA-PKCS1-v1_5).
+Objects signed with a padded scheme are hashed from their JSON encoding, while
+textbook signatures keep hashing the "%v" representation. Textbook signatures are only
+produced and verified when asked for by name, never for a missing scheme.
+**/
+
+package RSA
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math/big"
+)
+
+const DEFAULT_E = 65537
+
+/* Signature scheme identifiers, stored next to signatures in signed objects */
+const SCHEME_TEXTBOOK = "rsa-textbook"
+const SCHEME_PSS = "rsa-pss"
+const SCHEME_PKCS1V15 = "rsa-pkcs1v15"
+const DEFAULT_SCHEME = SCHEME_PSS
+
+/* DER encoding of the SHA-256 AlgorithmIdentifier, prefixed to the hash in PKCS#1 v1.5 */
+var sha256DigestInfo = []byte{0x30, 0x31, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x01, 0x05, 0x00, 0x04, 0x20}
+
+/* Hash the JSON encoding of an object with SHA-256 */
+func ComputeJSONHash(templateObject interface{}) []byte {
+	objectBytes, err := json.Marshal(templateObject)
+	if err != nil {
+		panic(err)
+	}
+	hash := sha256.Sum256(objectBytes)
+	return hash[:]
+}
+
+/* Sign an object with the given scheme */
+func Sign(templateObject interface{}, privateKeyString string, scheme string) (string, error) {
+	privateKey := ToKey(privateKeyString)
+	if privateKey.N == nil || privateKey.E_or_d == nil {
+		return "", errors.New("invalid private key")
+	}
+	var encodedMessage []byte
+	var err error
+	switch scheme {
+	case SCHEME_TEXTBOOK:
+		return GenerateSignature(templateObject, privateKeyString), nil
+	case SCHEME_PSS:
+		encodedMessage, err = emsaPSSEncode(ComputeJSONHash(templateObject), privateKey.N.BitLen()-1)
+	case SCHEME_PKCS1V15:
+		encodedMessage, err = emsaPKCS1v15Encode(ComputeJSONHash(templateObject), (privateKey.N.BitLen()+7)/8)
+	default:
+		return "", errors.New("unknown signature scheme " + scheme)
+	}
+	if err != nil {
+		return "", err
+	}
+	signature, err := SignRaw(new(big.Int).SetBytes(encodedMessage), privateKey)
+	if err != nil {
+		return "", err
+	}
+	return signature.String(), nil
+}
+
+/* Verify the signature of an object with the given scheme */
+func Verify(templateObject interface{}, signatureString string, publicKeyString string, scheme string) bool {
+	if scheme == SCHEME_TEXTBOOK {
+		return VerifySignature(templateObject, signatureString, publicKeyString)
+	}
+	publicKey := ToKey(publicKeyString)
+	signature, ok := new(big.Int).SetString(signatureString, 10)
+	if !ok || publicKey.N == nil || publicKey.E_or_d == nil || signature.Sign() < 0 || signature.Cmp(publicKey.N) >= 0 {
+		return false
+	}
+	m := Decrypt(signature, publicKey)
+	switch scheme {
+	case SCHEME_PSS:
+		emBits := publicKey.N.BitLen() - 1
+		encodedMessage, ok := i2osp(m, (emBits+7)/8)
+		return ok && emsaPSSVerify(ComputeJSONHash(templateObject), encodedMessage, emBits)
+	case SCHEME_PKCS1V15:
+		k := (publicKey.N.BitLen() + 7) / 8
+		encodedMessage, ok := i2osp(m, k)
+		if !ok {
+			return false
+		}
+		expected, err := emsaPKCS1v15Encode(ComputeJSONHash(templateObject), k)
+		return err == nil && bytes.Equal(encodedMessage, expected)
+	default:
+		return false
+	}
+}
+
+/* Convert an integer to a big-endian byte string of the given length */
+func i2osp(x *big.Int, length int) ([]byte, bool) {
+	xBytes := x.Bytes()
+	if len(xBytes) > length {
+		return nil, false
+	}
+	return append(make([]byte, length-len(xBytes)), xBytes...), true
+}
+
+/* Mask generation function MGF1 with SHA-256 */
+func mgf1(seed []byte, length int) []byte {
+	mask := make([]byte, 0, length+sha256.Size)
+	counter := make([]byte, 4)
+	for i := uint32(0); len(mask) < length; i++ {
+		binary.BigEndian.PutUint32(counter, i)
+		h := sha256.New()
+		h.Write(seed)
+		h.Write(counter)
+		mask = h.Sum(mask)
+	}
+	return mask[:length]
+}
+
+/* EMSA-PSS encoding of a message hash, with a salt as long as the hash */
+func emsaPSSEncode(messageHash []byte, emBits int) ([]byte, error) {
+	hLen, sLen := sha256.Size, sha256.Size
+	emLen := (emBits + 7) / 8
+	if emLen < hLen+sLen+2 {
+		return nil, errors.New("key too short for RSA-PSS")
+	}
+	salt := make([]byte, sLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	h := sha256.New()
+	h.Write(make([]byte, 8))
+	h.Write(messageHash)
+	h.Write(salt)
+	H := h.Sum(nil)
+
+	// DB = PS || 0x01 || salt, masked with MGF1(H)
+	db := make([]byte, emLen-hLen-1)
+	db[len(db)-sLen-1] = 0x01
+	copy(db[len(db)-sLen:], salt)
+	dbMask := mgf1(H, len(db))
+	for i := range db {
+		db[i] ^= dbMask[i]
+	}
+	db[0] &= 0xff >> uint(8*emLen-emBits)
+
+	encodedMessage := append(db, H...)
+	return append(encodedMessage, 0xbc), nil
+}
+
+/* EMSA-PSS verification of an encoded message against a message hash */
+func emsaPSSVerify(messageHash []byte, encodedMessage []byte, emBits int) bool {
+	hLen, sLen := sha256.Size, sha256.Size
+	emLen := (emBits + 7) / 8
+	if len(encodedMessage) != emLen || emLen < hLen+sLen+2 || encodedMessage[emLen-1] != 0xbc {
+		return false
+	}
+	db := append([]byte{}, encodedMessage[:emLen-hLen-1]...)
+	H := encodedMessage[emLen-hLen-1 : emLen-1]
+	if db[0]&^(0xff>>uint(8*emLen-emBits)) != 0 {
+		return false
+	}
+	dbMask := mgf1(H, len(db))
+	for i := range db {
+		db[i] ^= dbMask[i]
+	}
+	db[0] &= 0xff >> uint(8*emLen-emBits)
+	for _, b := range db[:len(db)-sLen-1] {
+		if b != 0 {
+			return false
+		}
+	}
+	if db[len(db)-sLen-1] != 0x01 {
+		return false
+	}
+	salt := db[len(db)-sLen:]
+	h := sha256.New()
+	h.Write(make([]byte, 8))
+	h.Write(messageHash)
+	h.Write(salt)
+	return bytes.Equal(h.Sum(nil), H)
+}
+
+/* EMSA-PKCS1-v1_5 encoding of a SHA-256 message hash */
+func emsaPKCS1v15Encode(messageHash []byte, emLen int) ([]byte, error) {
+	tLen := len(sha256DigestInfo) + len(messageHash)
+	if emLen < tLen+11 {
+		return nil, errors.New("key too short for RSA PKCS#1 v1.5")
+	}
+	encodedMessage := make([]byte, emLen)
+	encodedMessage[1] = 0x01
+	for i := 2; i < emLen-tLen-1; i++ {
+		encodedMessage[i] = 0xff
+	}
+	copy(encodedMessage[emLen-tLen:], sha256DigestInfo)
+	copy(encodedMessage[emLen-len(messageHash):], messageHash)
+	return encodedMessage, nil
+}
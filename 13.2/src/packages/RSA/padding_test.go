@@ -0,0 +1,42 @@
+package RSA
+
+import "testing"
+
+func TestSignVerifySchemes(t *testing.T) {
+	publicKey, privateKey, err := GenerateKey(2048, DEFAULT_E)
+	if err != nil {
+		t.Fatal(err)
+	}
+	object := struct{ Amount int }{Amount: 5}
+	for _, scheme := range []string{SCHEME_PSS, SCHEME_PKCS1V15} {
+		signature, err := Sign(object, privateKey.ToString(), scheme)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !Verify(object, signature, publicKey.ToString(), scheme) {
+			t.Error(scheme + " signature does not verify")
+		}
+		if Verify(struct{ Amount int }{Amount: 6}, signature, publicKey.ToString(), scheme) {
+			t.Error(scheme + " signature accepted for another object")
+		}
+	}
+}
+
+/* A signature without a scheme must not be verified as a textbook signature */
+func TestVerifyRejectsMissingScheme(t *testing.T) {
+	publicKey, privateKey, err := GenerateKey(2048, DEFAULT_E)
+	if err != nil {
+		t.Fatal(err)
+	}
+	object := struct{ Amount int }{Amount: 5}
+	signature, err := Sign(object, privateKey.ToString(), SCHEME_TEXTBOOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Verify(object, signature, publicKey.ToString(), "") {
+		t.Error("textbook signature accepted without a scheme")
+	}
+	if _, err := Sign(object, privateKey.ToString(), ""); err == nil {
+		t.Error("object signed without a scheme")
+	}
+}
@@ -0,0 +1,96 @@
+/**
+The construction follows RFC 9381, section 4. The proof is the RSA signature of a
+full-domain hash of the input, so it is unique for a key and an input, and the output
+is the SHA-256 hash of the proof. The proof is only unique if e is coprime to the order of
+the group, which is guaranteed for the prime e = 65537 with a modulus of at least
+VRF_MIN_MODULUS_BITS bits generated by GenerateKey, so VRF keys must use that exponent.
+**/
+
+package RSA
+
+import (
+	"crypto/sha256"
+	"errors"
+	"math/big"
+	"strconv"
+)
+
+const VRF_SUITE_RSA_FDH_SHA256 = 0x01
+const VRF_MIN_MODULUS_BITS = 2048
+
+const vrfMGFDomainSeparator = 0x01
+const vrfProofToHashDomainSeparator = 0x02
+
+/* Check that a public key may be used as VRF key, with e = 65537 and a large enough modulus */
+func ValidateVRFKey(publicKey Key) error {
+	if publicKey.N == nil || publicKey.E_or_d == nil || publicKey.IsPrivate() {
+		return errors.New("invalid RSA public key")
+	}
+	if publicKey.E_or_d.Cmp(big.NewInt(DEFAULT_E)) != 0 {
+		return errors.New("RSA VRF key must have the public exponent 65537")
+	}
+	if publicKey.N.BitLen() < VRF_MIN_MODULUS_BITS {
+		return errors.New("RSA VRF key must have a modulus of at least " + strconv.Itoa(VRF_MIN_MODULUS_BITS) + " bits")
+	}
+	return nil
+}
+
+/* Compute the VRF proof and output of an input with a private key */
+func ProveVRF(privateKey Key, alpha []byte) ([]byte, []byte, error) {
+	if privateKey.N == nil || privateKey.E_or_d == nil {
+		return nil, nil, errors.New("invalid RSA private key")
+	}
+	if privateKey.E == nil {
+		return nil, nil, errors.New("RSA private key lacks the public exponent needed to check it as VRF key")
+	}
+	if err := ValidateVRFKey(privateKey.PublicKey()); err != nil {
+		return nil, nil, err
+	}
+	k := (privateKey.N.BitLen() + 7) / 8
+	m := new(big.Int).SetBytes(vrfEncode(privateKey.N, alpha))
+	s, err := SignRaw(m, privateKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	proof, _ := i2osp(s, k)
+	return proof, VRFProofToHash(proof), nil
+}
+
+/* Verify a VRF proof of an input against a public key, returning the output if the proof is valid */
+func VerifyVRF(publicKey Key, alpha []byte, proof []byte) ([]byte, error) {
+	if err := ValidateVRFKey(publicKey); err != nil {
+		return nil, err
+	}
+	k := (publicKey.N.BitLen() + 7) / 8
+	if len(proof) != k {
+		return nil, errors.New("VRF proof has the wrong length")
+	}
+	s := new(big.Int).SetBytes(proof)
+	if s.Cmp(publicKey.N) >= 0 {
+		return nil, errors.New("VRF proof out of range")
+	}
+	m := new(big.Int).Exp(s, publicKey.E_or_d, publicKey.N)
+	if m.Cmp(new(big.Int).SetBytes(vrfEncode(publicKey.N, alpha))) != 0 {
+		return nil, errors.New("invalid VRF proof")
+	}
+	return VRFProofToHash(proof), nil
+}
+
+/* Output of a VRF proof. Only meaningful once the proof has been verified */
+func VRFProofToHash(proof []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{VRF_SUITE_RSA_FDH_SHA256, vrfProofToHashDomainSeparator})
+	h.Write(proof)
+	return h.Sum(nil)
+}
+
+/* Full-domain hash of the input, one byte shorter than the modulus so that it is below it */
+func vrfEncode(N *big.Int, alpha []byte) []byte {
+	k := (N.BitLen() + 7) / 8
+	kBytes, _ := i2osp(big.NewInt(int64(k)), 4)
+	nBytes, _ := i2osp(N, k)
+	seed := append([]byte{VRF_SUITE_RSA_FDH_SHA256, vrfMGFDomainSeparator}, kBytes...)
+	seed = append(seed, nBytes...)
+	seed = append(seed, alpha...)
+	return mgf1(seed, k-1)
+}
@@ -0,0 +1,99 @@
+/**
+Addresses are encoded with base58check as in Bitcoin: a version byte followed by
+the first 20 bytes of the SHA-256 hash of the public key and a 4 byte checksum,
+the first 4 bytes of the double SHA-256 hash of the version byte and the hash.
+**/
+
+package address
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+	"strings"
+)
+
+const VERSION = 0x17
+const HASH_LENGTH = 20
+const CHECKSUM_LENGTH = 4
+
+const alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+/* Derive the address of an account from its public key */
+func FromPublicKey(publicKey string) string {
+	hash := sha256.Sum256([]byte(publicKey))
+	payload := append([]byte{VERSION}, hash[:HASH_LENGTH]...)
+	return encodeBase58(append(payload, checksum(payload)...))
+}
+
+/* Check that a string is a well-formed address with a valid checksum */
+func Validate(address string) error {
+	decoded, err := decodeBase58(address)
+	if err != nil {
+		return err
+	}
+	if len(decoded) != 1+HASH_LENGTH+CHECKSUM_LENGTH {
+		return errors.New("address has invalid length")
+	}
+	if decoded[0] != VERSION {
+		return errors.New("address has unknown version")
+	}
+	payload := decoded[:1+HASH_LENGTH]
+	if !bytes.Equal(checksum(payload), decoded[1+HASH_LENGTH:]) {
+		return errors.New("address has invalid checksum")
+	}
+	return nil
+}
+
+/* Check that an address belongs to a public key */
+func Matches(address string, publicKey string) bool {
+	return address == FromPublicKey(publicKey)
+}
+
+/* First bytes of the double SHA-256 hash of the payload */
+func checksum(payload []byte) []byte {
+	first := sha256.Sum256(payload)
+	second := sha256.Sum256(first[:])
+	return second[:CHECKSUM_LENGTH]
+}
+
+/* Encode bytes in base58, keeping leading zero bytes as '1' */
+func encodeBase58(input []byte) string {
+	var result []byte
+	x := new(big.Int).SetBytes(input)
+	base := big.NewInt(int64(len(alphabet)))
+	mod := new(big.Int)
+	for x.Sign() > 0 {
+		x.DivMod(x, base, mod)
+		result = append(result, alphabet[mod.Int64()])
+	}
+	for _, b := range input {
+		if b != 0 {
+			break
+		}
+		result = append(result, alphabet[0])
+	}
+	for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+		result[i], result[j] = result[j], result[i]
+	}
+	return string(result)
+}
+
+/* Decode a base58 string */
+func decodeBase58(input string) ([]byte, error) {
+	x := new(big.Int)
+	base := big.NewInt(int64(len(alphabet)))
+	for _, c := range input {
+		digit := strings.IndexRune(alphabet, c)
+		if digit < 0 {
+			return nil, errors.New("address contains invalid character " + string(c))
+		}
+		x.Mul(x, base).Add(x, big.NewInt(int64(digit)))
+	}
+	leadingZeros := 0
+	for leadingZeros < len(input) && input[leadingZeros] == alphabet[0] {
+		leadingZeros++
+	}
+	return append(make([]byte, leadingZeros), x.Bytes()...), nil
+}
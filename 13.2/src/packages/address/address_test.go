@@ -0,0 +1,88 @@
+package address
+
+import (
+	"bytes"
+	"testing"
+)
+
+/* Encode a payload with its checksum, as FromPublicKey does */
+func encodeWithChecksum(payload []byte) string {
+	return encodeBase58(append(append([]byte{}, payload...), checksum(payload)...))
+}
+
+func TestBase58(t *testing.T) {
+	for _, vector := range []struct {
+		input   []byte
+		encoded string
+	}{
+		{[]byte("Hello World!"), "2NEpo7TZRRrLZSi2U"},
+		{[]byte{0, 0, 0x28, 0x7f, 0xb4, 0xcd}, "11233QC4"},
+		{[]byte{0}, "1"},
+		{[]byte{}, ""},
+	} {
+		if encoded := encodeBase58(vector.input); encoded != vector.encoded {
+			t.Errorf("%x encoded as %v", vector.input, encoded)
+		}
+		decoded, err := decodeBase58(vector.encoded)
+		if err != nil || !bytes.Equal(decoded, vector.input) {
+			t.Errorf("%v decoded as %x, %v", vector.encoded, decoded, err)
+		}
+	}
+}
+
+func TestFromPublicKey(t *testing.T) {
+	address := FromPublicKey("ed25519:00")
+	if err := Validate(address); err != nil {
+		t.Fatal(err)
+	}
+	if address[0] != 'A' {
+		t.Errorf("address %v does not start with the character of its version", address)
+	}
+	decoded, err := decodeBase58(address)
+	if err != nil || len(decoded) != 1+HASH_LENGTH+CHECKSUM_LENGTH || decoded[0] != VERSION {
+		t.Errorf("address decoded as %x, %v", decoded, err)
+	}
+	if !Matches(address, "ed25519:00") || Matches(address, "ed25519:01") {
+		t.Error("address matched to the wrong public key")
+	}
+}
+
+/* Changing any single character of an address is caught by the checksum */
+func TestValidateRejectsChangedCharacter(t *testing.T) {
+	address := FromPublicKey("ed25519:00")
+	for i := range address {
+		for _, c := range alphabet {
+			if byte(c) == address[i] {
+				continue
+			}
+			changed := address[:i] + string(c) + address[i+1:]
+			if Validate(changed) == nil {
+				t.Fatalf("address with character %v changed to %c accepted", i, c)
+			}
+		}
+	}
+}
+
+func TestValidateRejected(t *testing.T) {
+	hash := bytes.Repeat([]byte{0xab}, HASH_LENGTH)
+	address := FromPublicKey("ed25519:00")
+	for name, invalid := range map[string]string{
+		"empty address":            "",
+		"other version":            encodeWithChecksum(append([]byte{0x00}, hash...)),
+		"short hash":               encodeWithChecksum(append([]byte{VERSION}, hash[:HASH_LENGTH-1]...)),
+		"long hash":                encodeWithChecksum(append([]byte{VERSION}, append(hash, 0xab)...)),
+		"missing checksum":         encodeBase58(append([]byte{VERSION}, hash...)),
+		"truncated address":        address[:len(address)-1],
+		"zero, not in base58":      address[:5] + "0" + address[6:],
+		"capital O, not in base58": address[:5] + "O" + address[6:],
+		"capital I, not in base58": address[:5] + "I" + address[6:],
+		"small l, not in base58":   address[:5] + "l" + address[6:],
+	} {
+		if Validate(invalid) == nil {
+			t.Error(name + " accepted")
+		}
+	}
+	if Validate(encodeWithChecksum(append([]byte{VERSION}, hash...))) != nil {
+		t.Error("well-formed address rejected")
+	}
+}
@@ -1,79 +1,129 @@
 package blockchain
 
 import (
+	"container/heap"
+	"encoding/hex"
+	"encoding/json"
 	"math/big"
 	"packages/RSA"
 	"packages/ledger"
+	"packages/signature"
+	"packages/vrf"
+	"sort"
 	"time"
 )
 
 const SEED = 3
 const SLOT_LENGTH_SECONDS = 3
+const MAX_BLOCK_BYTES = 1 << 20
+const MAX_BLOCK_TXS = 1000
+const LOTTERY_OUTPUT_BYTES = 32
 
-// TODO: use draw as struct Draw instead of string
-func MakeDraw(seed int, slot int, sk string) string {
-	draw := new(Draw)
-	draw.Lottery = "lottery"
-	draw.Seed = seed
-	draw.Slot = slot
-	return RSA.GenerateSignature(draw, sk)
+/* Make the draw of a slot: the hex-encoded VRF proof of the lottery input, and the VRF output it proves */
+func MakeDraw(chainID string, seed int, slot int, signer signature.Signer) (string, []byte, error) {
+	proof, output, err := vrf.Prove(signer, lotteryInput(chainID, seed, slot))
+	if err != nil {
+		return "", nil, err
+	}
+	return hex.EncodeToString(proof), output, nil
+}
+
+/* Check whether a VRF output wins the lottery. A draw wins with probability tickets/hardness, whatever the key size */
+func IsWinner(output []byte, tickets int, hardness *big.Int) bool {
+	if tickets <= 0 || len(output) < LOTTERY_OUTPUT_BYTES {
+		return false
+	}
+	// the first 256 bits of the output are a uniform fraction of 2^256, which wins if it is below tickets/hardness
+	drawValue := new(big.Int).SetBytes(output[:LOTTERY_OUTPUT_BYTES])
+	drawValue.Mul(drawValue, hardness)
+	threshold := new(big.Int).Lsh(big.NewInt(int64(tickets)), 8*LOTTERY_OUTPUT_BYTES)
+	return drawValue.Cmp(threshold) < 0
 }
 
-func IsWinner(draw string, tickets int, hardness *big.Int) bool {
-	drawHash := RSA.ByteArrayToInt(RSA.ComputeHash(draw))
-	ticketsBigInt := big.NewInt(int64(tickets)) //TODO: make tickets into big Int
-	drawValue := big.NewInt(0).Mul(drawHash, ticketsBigInt)
-	return drawValue.Cmp(hardness) == 0 || drawValue.Cmp(hardness) == 1
+/* Verify the VRF proof of a draw against the verification key of its creator, and that it wins the lottery */
+func VerifyWinner(drawToVerify string, tickets int, hardness *big.Int, vk string, chainID string, seed int, slot int) bool {
+	proof, err := hex.DecodeString(drawToVerify)
+	if err != nil {
+		return false
+	}
+	output, err := vrf.Verify(vk, lotteryInput(chainID, seed, slot), proof)
+	if err != nil {
+		return false
+	}
+	return IsWinner(output, tickets, hardness)
 }
 
-func VerifyWinner(drawToVerify string, tickets int, hardness *big.Int, vk string, seed int, slot int) bool {
+/* Input of the lottery VRF, the JSON encoding of the draw of a slot */
+func lotteryInput(chainID string, seed int, slot int) []byte {
 	draw := new(Draw)
 	draw.Lottery = "lottery"
+	draw.ChainID = chainID
 	draw.Seed = seed
 	draw.Slot = slot
-	if !RSA.VerifySignature(draw, drawToVerify, vk) {
-		return false
+	input, err := json.Marshal(draw)
+	if err != nil {
+		panic(err)
 	}
-	return IsWinner(drawToVerify, tickets, hardness)
+	return input
 }
 
-func MakeSignedBlock(slot int, draw string, sk string, vk string, transactions []ledger.SignedTransaction) *SignedBlock {
+/* Make a block signed with the key of its creator, for an account whose consensus key it is (or for the key's own account if empty) */
+func MakeSignedBlock(chainID string, account string, slot int, draw string, signer signature.Signer, transactions []ledger.SignedTransaction) (*SignedBlock, error) {
 	block := new(Block)
-	block.Vk = vk
+	block.ChainID = chainID
+	block.Account = account
+	block.Vk = signer.PublicKey()
 	block.Slot = slot
 	block.Draw = draw
 	block.BlockData = transactions
 	block.Hash = RSA.ByteArrayToInt(RSA.ComputeHash(block)).String()
 	//block.NextBlocksHashes = make([]string, 0, 1)
-	signedBlock := new(SignedBlock)
-	signedBlock.Type = "signedBlock"
-	signedBlock.Block = block
-	signedBlock.Signature = RSA.GenerateSignature(signedBlock.Block, sk)
-	return signedBlock
+	return signBlock(block, signer)
+}
+
+/* Verify the signature of a block against the verification key of its creator */
+func VerifyBlockSignature(signedBlock *SignedBlock) bool {
+	return signedBlock.Block != nil && signature.Verify(signedBlock.Block, signedBlock.Signature, signedBlock.Block.Vk, signedBlock.Scheme)
 }
 
-func MakeGenesisBlock(slot int, draw string, sk string, vk string) *SignedBlock {
+func MakeGenesisBlock(chainID string, slot int, draw string, signer signature.Signer) (*SignedBlock, error) {
 	block := new(Block)
-	block.Vk = vk
+	block.ChainID = chainID
+	block.Vk = signer.PublicKey()
 	block.Slot = slot
 	block.Draw = draw
 	block.Hash = RSA.ByteArrayToInt(RSA.ComputeHash(block)).String()
 	block.PreviousBlockHash = ""
 	//block.NextBlocksHashes = make([]string, 0, 1)
+	return signBlock(block, signer)
+}
+
+func signBlock(block *Block, signer signature.Signer) (*SignedBlock, error) {
 	signedBlock := new(SignedBlock)
 	signedBlock.Type = "signedBlock"
 	signedBlock.Block = block
-	signedBlock.Signature = RSA.GenerateSignature(signedBlock.Block, sk)
-	return signedBlock
+	scheme, blockSignature, err := signer.Sign(signedBlock.Block)
+	if err != nil {
+		return nil, err
+	}
+	signedBlock.Scheme = scheme
+	signedBlock.Signature = blockSignature
+	return signedBlock, nil
 }
 
 func MakeBlockchain() *Blockchain {
+	return MakeBlockchainFromGenesis(DefaultGenesis(DEFAULT_NETWORK))
+}
+
+func MakeBlockchainFromGenesis(genesis Genesis) *Blockchain {
 	blockchain := new(Blockchain)
+	blockchain.ChainID = genesis.ChainID()
 	blockchain.BlocksMap = make(map[string]Block)
-	blockchain.Seed = SEED
-	blockchain.Hardness = new(big.Int)
-	blockchain.Hardness, _ = blockchain.Hardness.SetString("98101277522421650198781678972208785932907589725093492146067428082680095847419000000", 10)
-	blockchain.SlotLengthSeconds = SLOT_LENGTH_SECONDS
+	blockchain.Seed = genesis.Seed
+	blockchain.Hardness = genesis.HardnessInt()
+	blockchain.SlotLengthSeconds = genesis.SlotLengthSeconds
+	blockchain.MaxBlockBytes = genesis.MaxBlockBytes
+	blockchain.MaxBlockTxs = genesis.MaxBlockTxs
 	return blockchain
 }
 
@@ -102,3 +152,142 @@ func (blockchain *Blockchain) GetSlotNumber() int {
 /* func (blockchain *Blockchain) GetLongestChainLeaf() (int, string) {
 	return blockchain.GenesisBlock.GetLongestChainLeaf()
 } */
+
+/* Size of a transaction in bytes, as it is sent on the network */
+func TransactionSize(transaction ledger.SignedTransaction) int {
+	jsonString, err := json.Marshal(transaction)
+	if err != nil {
+		panic(err)
+	}
+	return len(jsonString)
+}
+
+/* Size of the transactions of a block in bytes */
+func BlockDataSize(transactions []ledger.SignedTransaction) int {
+	size := 0
+	for _, transaction := range transactions {
+		size += TransactionSize(transaction)
+	}
+	return size
+}
+
+/* Check that a block respects the size limits of the chain */
+func (blockchain *Blockchain) ValidateBlockSize(block *Block) bool {
+	return len(block.BlockData) <= blockchain.MaxBlockTxs && BlockDataSize(block.BlockData) <= blockchain.MaxBlockBytes
+}
+
+/* Check that the slot of a block has started by the local clock and comes after the slot of the last accepted block */
+func (blockchain *Blockchain) ValidateSlot(block *Block, lastSlot int) bool {
+	return block.Slot > lastSlot && block.Slot <= blockchain.GetSlotNumber()
+}
+
+/* Check that a block contains no transactions that expired before the slot of the block */
+func ValidateTransactionExpiry(block *Block) bool {
+	for _, transaction := range block.BlockData {
+		if transaction.Transaction.IsExpired(block.Slot) {
+			return false
+		}
+	}
+	return true
+}
+
+/* Check that a block and all of its transactions belong to the given chain */
+func ValidateChainID(block *Block, chainID string) bool {
+	if block.ChainID != chainID {
+		return false
+	}
+	for _, transaction := range block.BlockData {
+		if transaction.Transaction.ChainID != chainID {
+			return false
+		}
+	}
+	return true
+}
+
+/* Queue of the next includable transaction of every sender, ordered by fee rate */
+type senderQueue struct {
+	heads   []ledger.SignedTransaction
+	rates   map[string]int
+	pending map[string][]ledger.SignedTransaction // sender -> remaining transactions in nonce order
+}
+
+func (queue *senderQueue) Len() int { return len(queue.heads) }
+
+func (queue *senderQueue) Less(i, j int) bool {
+	rateI, rateJ := queue.rates[queue.heads[i].Transaction.ID], queue.rates[queue.heads[j].Transaction.ID]
+	if rateI != rateJ {
+		return rateI > rateJ
+	}
+	return queue.heads[i].Transaction.ID < queue.heads[j].Transaction.ID
+}
+
+func (queue *senderQueue) Swap(i, j int) {
+	queue.heads[i], queue.heads[j] = queue.heads[j], queue.heads[i]
+}
+
+func (queue *senderQueue) Push(x interface{}) {
+	queue.heads = append(queue.heads, x.(ledger.SignedTransaction))
+}
+
+func (queue *senderQueue) Pop() interface{} {
+	last := queue.heads[len(queue.heads)-1]
+	queue.heads = queue.heads[:len(queue.heads)-1]
+	return last
+}
+
+/* Select the transactions of a new block from the pending transactions */
+func SelectTransactions(pending []ledger.SignedTransaction, nextNonce func(account string) int, maxBytes int, maxTxs int) []ledger.SignedTransaction {
+	// Transactions are taken by fee rate (fee per kilobyte), while the transactions of
+	// each sender are taken in nonce order without gaps, starting at the sender's next nonce.
+	// The result only depends on the set of pending transactions, not on their order.
+	queue := &senderQueue{rates: make(map[string]int), pending: make(map[string][]ledger.SignedTransaction)}
+	sizes := make(map[string]int)
+	for _, transaction := range pending {
+		size := TransactionSize(transaction)
+		sizes[transaction.Transaction.ID] = size
+		queue.rates[transaction.Transaction.ID] = transaction.Transaction.Fee * 1000 / size
+		queue.pending[transaction.Transaction.From] = append(queue.pending[transaction.Transaction.From], transaction)
+	}
+
+	// order the transactions of each sender by nonce, and start at the sender's next nonce
+	for sender, transactions := range queue.pending {
+		sort.Slice(transactions, func(i, j int) bool {
+			if transactions[i].Transaction.Nonce != transactions[j].Transaction.Nonce {
+				return transactions[i].Transaction.Nonce < transactions[j].Transaction.Nonce
+			}
+			// of two transactions with the same nonce, prefer the one paying the higher fee
+			if transactions[i].Transaction.Fee != transactions[j].Transaction.Fee {
+				return transactions[i].Transaction.Fee > transactions[j].Transaction.Fee
+			}
+			return transactions[i].Transaction.ID < transactions[j].Transaction.ID
+		})
+		if transactions[0].Transaction.Nonce == nextNonce(sender) {
+			queue.heads = append(queue.heads, transactions[0])
+			queue.pending[sender] = transactions[1:]
+		}
+	}
+	heap.Init(queue)
+
+	selected := make([]ledger.SignedTransaction, 0)
+	blockBytes := 0
+	for queue.Len() > 0 && len(selected) < maxTxs {
+		best := heap.Pop(queue).(ledger.SignedTransaction)
+		// if the transaction does not fit, none of the later transactions of the sender can be included
+		if blockBytes+sizes[best.Transaction.ID] > maxBytes {
+			continue
+		}
+		selected = append(selected, best)
+		blockBytes += sizes[best.Transaction.ID]
+
+		// the next transaction of the sender must continue the nonce sequence
+		sender := best.Transaction.From
+		for len(queue.pending[sender]) > 0 && queue.pending[sender][0].Transaction.Nonce <= best.Transaction.Nonce {
+			queue.pending[sender] = queue.pending[sender][1:]
+		}
+		if len(queue.pending[sender]) > 0 && queue.pending[sender][0].Transaction.Nonce == best.Transaction.Nonce+1 {
+			heap.Push(queue, queue.pending[sender][0])
+			queue.pending[sender] = queue.pending[sender][1:]
+		}
+	}
+	return selected
+}
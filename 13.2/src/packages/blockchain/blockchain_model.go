@@ -9,6 +9,7 @@ import (
 /* Lottery draw struct */
 type Draw struct {
 	Lottery string // "lottery"
+	ChainID string // Chain ID of the network
 	Seed    int    // Seed
 	Slot    int    // Slot number
 }
@@ -16,9 +17,11 @@ type Draw struct {
 /* Block struct */
 type Block struct {
 	Type              string                     // block
+	ChainID           string                     // Chain ID of the network
 	Vk                string                     // Verification key of the block (vk), signifies the creator of the block
+	Account           string                     `json:",omitempty"` // Validator account the block is created for, if vk is its consensus key
 	Slot              int                        // Slot number (block number)
-	Draw              string                     // Draw that was used to win the lottery
+	Draw              string                     // Draw that was used to win the lottery, the hex-encoded VRF proof
 	BlockData         []ledger.SignedTransaction // List of transactions contained in the block (U)
 	Hash              string                     //	Hash of the block
 	PreviousBlockHash string                     // Hash of the previous block (h)
@@ -30,16 +33,20 @@ type Block struct {
 type SignedBlock struct {
 	Type      string // signedBlock
 	Block     *Block // Block
+	Scheme    string // Signature scheme of the block
 	Signature string // Block signature (sigma)
 	BlockLock sync.Mutex
 }
 
 /* Blockchain struct */
 type Blockchain struct {
+	ChainID           string           // Chain ID of the network, derived from the genesis document
 	BlocksMap         map[string]Block // List of blocks containing signed transactions
 	GenesisBlock      Block            // Genesis block of the blockchain
 	Seed              int
 	Hardness          *big.Int
 	SlotLengthSeconds int
+	MaxBlockBytes     int // Maximum total size of the transactions in a block
+	MaxBlockTxs       int // Maximum number of transactions in a block
 	blockchainLock    sync.Mutex
 }
@@ -0,0 +1,26 @@
+package blockchain
+
+import "testing"
+
+/* A block must be for a slot that has started, after the slot of the last accepted block */
+func TestValidateSlot(t *testing.T) {
+	blockchain := MakeBlockchain()
+	current := blockchain.GetSlotNumber()
+	lastSlot := current - 10
+	for _, test := range []struct {
+		name  string
+		slot  int
+		valid bool
+	}{
+		{"current slot", current, true},
+		{"slot after the last block", lastSlot + 1, true},
+		{"future slot", current + 1, false},
+		{"far future slot", current + 1000000, false},
+		{"slot of the last block", lastSlot, false},
+		{"slot before the last block", lastSlot - 1, false},
+	} {
+		if valid := blockchain.ValidateSlot(&Block{Slot: test.slot}, lastSlot); valid != test.valid {
+			t.Errorf("%v: got valid = %v", test.name, valid)
+		}
+	}
+}
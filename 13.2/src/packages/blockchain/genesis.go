@@ -0,0 +1,84 @@
+package blockchain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math/big"
+)
+
+const DEFAULT_NETWORK = "dev"
+const DEFAULT_HARDNESS = "6000000"
+
+/* Genesis document struct, containing the parameters every peer of a network agrees on */
+type Genesis struct {
+	Network           string // Name of the network (dev, staging, prod, ...)
+	Seed              int    // Seed of the lottery
+	Hardness          string // Hardness of the lottery (decimal), the number of tickets that win every slot
+	SlotLengthSeconds int    // Length of a slot in seconds
+	MaxBlockBytes     int    // Maximum total size of the transactions in a block
+	MaxBlockTxs       int    // Maximum number of transactions in a block
+}
+
+/* Genesis document of a network with the default chain parameters */
+func DefaultGenesis(network string) Genesis {
+	return Genesis{
+		Network:           network,
+		Seed:              SEED,
+		Hardness:          DEFAULT_HARDNESS,
+		SlotLengthSeconds: SLOT_LENGTH_SECONDS,
+		MaxBlockBytes:     MAX_BLOCK_BYTES,
+		MaxBlockTxs:       MAX_BLOCK_TXS,
+	}
+}
+
+/* Read a genesis document from a JSON file. Parameters left out of the file keep their default values */
+func LoadGenesis(filename string) (Genesis, error) {
+	genesis := DefaultGenesis(DEFAULT_NETWORK)
+	genesisBytes, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return Genesis{}, err
+	}
+	if err := json.Unmarshal(genesisBytes, &genesis); err != nil {
+		return Genesis{}, err
+	}
+	if err := genesis.Validate(); err != nil {
+		return Genesis{}, errors.New("invalid genesis document " + filename + ": " + err.Error())
+	}
+	return genesis, nil
+}
+
+/* Check that the parameters of a genesis document can run a chain */
+func (genesis Genesis) Validate() error {
+	if genesis.SlotLengthSeconds <= 0 {
+		return errors.New("slot length must be positive")
+	}
+	if genesis.MaxBlockBytes <= 0 || genesis.MaxBlockTxs <= 0 {
+		return errors.New("block limits must be positive")
+	}
+	if hardness, ok := new(big.Int).SetString(genesis.Hardness, 10); !ok || hardness.Sign() <= 0 {
+		return errors.New("hardness must be a positive decimal number")
+	}
+	return nil
+}
+
+/* Chain ID of the network, the hex-encoded hash of the genesis document */
+func (genesis Genesis) ChainID() string {
+	genesisBytes, err := json.Marshal(genesis)
+	if err != nil {
+		panic(err)
+	}
+	hash := sha256.Sum256(genesisBytes)
+	return hex.EncodeToString(hash[:16])
+}
+
+/* Hardness of the lottery as an integer */
+func (genesis Genesis) HardnessInt() *big.Int {
+	hardness, ok := new(big.Int).SetString(genesis.Hardness, 10)
+	if !ok {
+		panic("invalid hardness in genesis document: " + genesis.Hardness)
+	}
+	return hardness
+}
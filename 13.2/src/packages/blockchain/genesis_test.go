@@ -0,0 +1,44 @@
+package blockchain
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func writeGenesis(t *testing.T, contents string) string {
+	filename := filepath.Join(t.TempDir(), "genesis.json")
+	if err := ioutil.WriteFile(filename, []byte(contents), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+/* Parameters left out of a genesis file keep their default values */
+func TestLoadGenesisDefaults(t *testing.T) {
+	genesis, err := LoadGenesis(writeGenesis(t, `{"Network": "staging", "Seed": 7}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := DefaultGenesis("staging")
+	expected.Seed = 7
+	if genesis != expected {
+		t.Errorf("loaded %+v", genesis)
+	}
+}
+
+func TestLoadGenesisRejected(t *testing.T) {
+	for name, contents := range map[string]string{
+		"zero slot length":        `{"SlotLengthSeconds": 0}`,
+		"negative slot length":    `{"SlotLengthSeconds": -1}`,
+		"zero block size":         `{"MaxBlockBytes": 0}`,
+		"zero block transactions": `{"MaxBlockTxs": 0}`,
+		"non-decimal hardness":    `{"Hardness": "0x10"}`,
+		"zero hardness":           `{"Hardness": "0"}`,
+		"malformed JSON":          `{"Network": `,
+	} {
+		if _, err := LoadGenesis(writeGenesis(t, contents)); err == nil {
+			t.Error(name + " accepted")
+		}
+	}
+}
@@ -0,0 +1,219 @@
+/**
+The message is encrypted with AES-256-GCM under a fresh key. For RSA public keys the AES key
+is encrypted with RSA-OAEP (SHA-256). For Ed25519 public keys the key is converted to its
+X25519 (Montgomery) form, and the AES key is derived with HKDF-SHA256 from an X25519 key
+agreement with a fresh ephemeral key. The address of the recipient's account is authenticated with
+the message. It is not derived from the public key, since the key of an account can be rotated.
+**/
+
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"packages/RSA"
+	"packages/address"
+	"packages/signature"
+	"strings"
+)
+
+const SCHEME_RSA_OAEP = "rsa-oaep-aes256gcm"
+const SCHEME_X25519 = "x25519-aes256gcm"
+
+const hkdfInfo = "static-proof-of-stake encryption"
+
+/* Field prime of Curve25519, 2^255 - 19 */
+var curvePrime = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 255), big.NewInt(19))
+
+/* Envelope struct, an encrypted message addressed to an account */
+type Envelope struct {
+	Scheme     string // Encryption scheme
+	Recipient  string // Address of the recipient's account
+	WrappedKey string // Hex-encoded RSA-OAEP encrypted AES key, or ephemeral X25519 public key
+	Nonce      string // Hex-encoded AES-GCM nonce
+	Ciphertext string // Hex-encoded AES-GCM ciphertext
+}
+
+/* Encrypt a message to an account, under the current public key of the account */
+func Encrypt(plaintext []byte, recipient string, recipientPublicKey string) (*Envelope, error) {
+	if err := address.Validate(recipient); err != nil {
+		return nil, err
+	}
+	envelope := &Envelope{Recipient: recipient}
+	var key []byte
+	switch signature.KeyType(recipientPublicKey) {
+	case signature.KEY_TYPE_ED25519:
+		recipient, err := x25519PublicKey(recipientPublicKey)
+		if err != nil {
+			return nil, err
+		}
+		ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		shared, err := ephemeral.ECDH(recipient)
+		if err != nil {
+			return nil, err
+		}
+		envelope.Scheme = SCHEME_X25519
+		envelope.WrappedKey = hex.EncodeToString(ephemeral.PublicKey().Bytes())
+		key = hkdf(shared, append(ephemeral.PublicKey().Bytes(), recipient.Bytes()...))
+	default:
+		publicKey := RSA.ToKey(recipientPublicKey)
+		rsaPublicKey, err := publicKey.ToRSAPublicKey()
+		if err != nil {
+			return nil, err
+		}
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		wrappedKey, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, rsaPublicKey, key, []byte(envelope.Recipient))
+		if err != nil {
+			return nil, err
+		}
+		envelope.Scheme = SCHEME_RSA_OAEP
+		envelope.WrappedKey = hex.EncodeToString(wrappedKey)
+	}
+
+	aead, err := makeAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	envelope.Nonce = hex.EncodeToString(nonce)
+	envelope.Ciphertext = hex.EncodeToString(aead.Seal(nil, nonce, plaintext, envelope.associatedData()))
+	return envelope, nil
+}
+
+/* Decrypt an envelope addressed to an account with the current private key of the account */
+func Decrypt(envelope *Envelope, account string, signer signature.Signer) ([]byte, error) {
+	if envelope.Recipient != account {
+		return nil, errors.New("message is addressed to another account")
+	}
+	wrappedKey, err := hex.DecodeString(envelope.WrappedKey)
+	if err != nil {
+		return nil, err
+	}
+	var key []byte
+	switch envelope.Scheme {
+	case SCHEME_X25519:
+		if signer.KeyType() != signature.KEY_TYPE_ED25519 {
+			return nil, errors.New("X25519 message requires an Ed25519 key")
+		}
+		privateKey, err := x25519PrivateKey(signer.PrivateKey())
+		if err != nil {
+			return nil, err
+		}
+		ephemeral, err := ecdh.X25519().NewPublicKey(wrappedKey)
+		if err != nil {
+			return nil, err
+		}
+		shared, err := privateKey.ECDH(ephemeral)
+		if err != nil {
+			return nil, err
+		}
+		key = hkdf(shared, append(wrappedKey, privateKey.PublicKey().Bytes()...))
+	case SCHEME_RSA_OAEP:
+		privateKey := RSA.ToKey(signer.PrivateKey())
+		rsaPrivateKey, err := privateKey.ToRSAPrivateKey()
+		if err != nil {
+			return nil, err
+		}
+		key, err = rsa.DecryptOAEP(sha256.New(), rand.Reader, rsaPrivateKey, wrappedKey, []byte(envelope.Recipient))
+		if err != nil {
+			return nil, errors.New("message could not be decrypted")
+		}
+	default:
+		return nil, errors.New("unknown encryption scheme " + envelope.Scheme)
+	}
+
+	aead, err := makeAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err1 := hex.DecodeString(envelope.Nonce)
+	ciphertext, err2 := hex.DecodeString(envelope.Ciphertext)
+	if err1 != nil || err2 != nil || len(nonce) != aead.NonceSize() {
+		return nil, errors.New("malformed message")
+	}
+	plaintext, err := aead.Open(nil, nonce, ciphertext, envelope.associatedData())
+	if err != nil {
+		return nil, errors.New("message could not be decrypted")
+	}
+	return plaintext, nil
+}
+
+func (envelope *Envelope) associatedData() []byte {
+	return []byte(envelope.Scheme + "\n" + envelope.Recipient)
+}
+
+func makeAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+/* HKDF-SHA256 (RFC 5869) deriving a 32 byte key */
+func hkdf(secret []byte, salt []byte) []byte {
+	extract := hmac.New(sha256.New, salt)
+	extract.Write(secret)
+	expand := hmac.New(sha256.New, extract.Sum(nil))
+	expand.Write([]byte(hkdfInfo))
+	expand.Write([]byte{1})
+	return expand.Sum(nil)
+}
+
+/* Convert an Ed25519 public key to X25519, u = (1 + y) / (1 - y) */
+func x25519PublicKey(publicKey string) (*ecdh.PublicKey, error) {
+	keyBytes, err := hex.DecodeString(strings.TrimPrefix(publicKey, signature.KEY_TYPE_ED25519+":"))
+	if err != nil || len(keyBytes) != 32 {
+		return nil, errors.New("invalid Ed25519 public key")
+	}
+	/* The key is the little-endian y-coordinate, with the sign of x in the top bit */
+	yBytes := make([]byte, 32)
+	for i := range keyBytes {
+		yBytes[31-i] = keyBytes[i]
+	}
+	yBytes[0] &= 0x7f
+	y := new(big.Int).SetBytes(yBytes)
+	one := big.NewInt(1)
+	denominator := new(big.Int).Sub(one, y)
+	denominator.Mod(denominator, curvePrime)
+	if denominator.Sign() == 0 {
+		return nil, errors.New("Ed25519 public key has no X25519 form")
+	}
+	u := new(big.Int).Add(one, y)
+	u.Mul(u, new(big.Int).ModInverse(denominator, curvePrime)).Mod(u, curvePrime)
+
+	uBytes := make([]byte, 32)
+	u.FillBytes(uBytes)
+	for i, j := 0, 31; i < j; i, j = i+1, j-1 {
+		uBytes[i], uBytes[j] = uBytes[j], uBytes[i]
+	}
+	return ecdh.X25519().NewPublicKey(uBytes)
+}
+
+/* Convert an Ed25519 private key to X25519, the first half of the SHA-512 hash of the seed */
+func x25519PrivateKey(privateKey string) (*ecdh.PrivateKey, error) {
+	keyBytes, err := hex.DecodeString(strings.TrimPrefix(privateKey, signature.KEY_TYPE_ED25519+":"))
+	if err != nil || len(keyBytes) != 64 {
+		return nil, errors.New("invalid Ed25519 private key")
+	}
+	hash := sha512.Sum512(keyBytes[:32])
+	return ecdh.X25519().NewPrivateKey(hash[:32])
+}
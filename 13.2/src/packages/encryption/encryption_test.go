@@ -0,0 +1,48 @@
+package encryption
+
+import (
+	"packages/address"
+	"packages/signature"
+	"testing"
+)
+
+/* A message to a rotated account is encrypted to its current key, but addressed to the unchanged account address */
+func TestEncryptToRotatedAccount(t *testing.T) {
+	for _, keyType := range []string{signature.KEY_TYPE_RSA, signature.KEY_TYPE_ED25519} {
+		firstKey, err := signature.GenerateSigner(keyType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		currentKey, err := signature.GenerateSigner(keyType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		account := address.FromPublicKey(firstKey.PublicKey())
+
+		envelope, err := Encrypt([]byte("memo"), account, currentKey.PublicKey())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if envelope.Recipient != account {
+			t.Error(keyType + " envelope not addressed to the account")
+		}
+		if plaintext, err := Decrypt(envelope, account, currentKey); err != nil || string(plaintext) != "memo" {
+			t.Error(keyType + " envelope not decrypted by the current key of the account")
+		}
+		if _, err := Decrypt(envelope, account, firstKey); err == nil {
+			t.Error(keyType + " envelope decrypted by the old key of the account")
+		}
+		if _, err := Decrypt(envelope, address.FromPublicKey(currentKey.PublicKey()), currentKey); err == nil {
+			t.Error(keyType + " envelope decrypted for another account")
+		}
+		// the recipient is authenticated, so it cannot be changed in transit
+		redirected := *envelope
+		redirected.Recipient = address.FromPublicKey(currentKey.PublicKey())
+		if _, err := Decrypt(&redirected, redirected.Recipient, currentKey); err == nil {
+			t.Error(keyType + " envelope with a changed recipient decrypted")
+		}
+	}
+	if _, err := Encrypt([]byte("memo"), "account", ""); err == nil {
+		t.Error("message encrypted to an invalid address")
+	}
+}
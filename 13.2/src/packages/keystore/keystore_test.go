@@ -0,0 +1,73 @@
+package keystore
+
+import (
+	"bytes"
+	"encoding/hex"
+	"os"
+	"testing"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+/* Test vectors from RFC 7914, section 12 */
+func TestScryptVectors(t *testing.T) {
+	vectors := []struct {
+		password string
+		salt     string
+		N, r, p  int
+		key      string
+	}{
+		{"", "", 16, 1, 1, "77d6576238657b203b19ca42c18a0497f16b4844e3074ae8dfdffa3fede21442fcd0069ded0948f8326a753a0fc81f17e8d3e0fb2e0d3628cf35e20c38d18906"},
+		{"password", "NaCl", 1024, 8, 16, "fdbabe1c9d3472007856e7190d01e9fe7c6ad7cbc8237830e77376634b3731622eaf30d92e22a3886ff109279d9830dac727afb94a83ee6d8360cbdfa2cc0640"},
+		{"pleaseletmein", "SodiumChloride", 16384, 8, 1, "7023bdcb3afd7348461c06cd81fd38ebfda8fbba904f8e3ea9b543f6545da1f2d5432955613f0fcf62d49705242a9af9e61e85dc0d651e40dfcf017b45575887"},
+	}
+	for _, vector := range vectors {
+		key, err := scrypt.Key([]byte(vector.password), []byte(vector.salt), vector.N, vector.r, vector.p, 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, _ := hex.DecodeString(vector.key)
+		if !bytes.Equal(key, expected) {
+			t.Errorf("scrypt(%q, %q, %v, %v, %v) = %x", vector.password, vector.salt, vector.N, vector.r, vector.p, key)
+		}
+	}
+}
+
+func TestStoreLoad(t *testing.T) {
+	store, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Store("node", "password", "private", "public"); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(store.path("node"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("key file has mode %v", info.Mode().Perm())
+	}
+	if err := store.Store("node", "password", "private", "public"); err != ErrKeyExists {
+		t.Errorf("storing an existing key gave %v", err)
+	}
+	privateKey, publicKey, err := store.Load("node", "password")
+	if err != nil || privateKey != "private" || publicKey != "public" {
+		t.Errorf("loaded %q, %q, %v", privateKey, publicKey, err)
+	}
+	if _, _, err := store.Load("node", "wrong"); err != ErrWrongPassword {
+		t.Errorf("loading with a wrong password gave %v", err)
+	}
+	if _, _, err := store.Load("other", "password"); err != ErrKeyNotFound {
+		t.Errorf("loading a missing key gave %v", err)
+	}
+	if err := store.ChangePassword("node", "password", "new password"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := store.Load("node", "password"); err != ErrWrongPassword {
+		t.Errorf("old password still opens the key: %v", err)
+	}
+	if privateKey, _, err := store.Load("node", "new password"); err != nil || privateKey != "private" {
+		t.Errorf("loaded %q, %v with the new password", privateKey, err)
+	}
+}
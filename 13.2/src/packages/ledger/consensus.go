@@ -0,0 +1,75 @@
+/**
+A validator authorises a consensus key with a transaction signed by its account key. The
+consensus key then plays the lottery with the stake of the account and signs its blocks,
+while rewards go to the account, so the account key can be kept offline. From then on the
+account key no longer produces blocks, so the stake is only drawn against once. Registering
+another key replaces the previous one without moving the stake.
+**/
+
+package ledger
+
+import (
+	"errors"
+	"packages/address"
+	"packages/signature"
+	"packages/vrf"
+)
+
+const KIND_REGISTER_CONSENSUS_KEY = "registerConsensusKey"
+
+func (ledger *Ledger) checkConsensusKey(transaction Transaction) error {
+	if _, err := signature.ParseVerifier(transaction.ConsensusKey); err != nil {
+		return errors.New("consensus key is not a valid public key")
+	}
+	if err := vrf.ValidateKey(transaction.ConsensusKey); err != nil {
+		return errors.New("consensus key cannot draw in the lottery: " + err.Error())
+	}
+	if account, found := ledger.ConsensusKeyAccount(transaction.ConsensusKey); found && account != transaction.From {
+		return errors.New("consensus key is already registered for another account")
+	}
+	if transaction.Amount != 0 {
+		return errors.New("consensus key registration must not send an amount")
+	}
+	if transaction.Fee > ledger.GetBalance(transaction.From) {
+		return errors.New("insufficient funds in the sender's account")
+	}
+	return nil
+}
+
+/* Get the consensus key registered for an account */
+func (ledger *Ledger) GetConsensusKey(account string) (string, bool) {
+	ledger.LedgerLock.Lock()
+	defer ledger.LedgerLock.Unlock()
+	consensusKey, found := ledger.ConsensusKeys[account]
+	return consensusKey, found
+}
+
+/* Get the account a consensus key is registered for */
+func (ledger *Ledger) ConsensusKeyAccount(consensusKey string) (string, bool) {
+	ledger.LedgerLock.Lock()
+	defer ledger.LedgerLock.Unlock()
+	for account, registeredKey := range ledger.ConsensusKeys {
+		if registeredKey == consensusKey {
+			return account, true
+		}
+	}
+	return "", false
+}
+
+/* Get the account a block is created for, checking that its key may sign for it. Without an account the key signs for its own account */
+func (ledger *Ledger) ValidatorAccount(account string, blockKey string) (string, error) {
+	if account == "" {
+		account = address.FromPublicKey(blockKey)
+	}
+	// once a consensus key is registered, only it may draw against the stake of the account
+	if consensusKey, found := ledger.GetConsensusKey(account); found {
+		if consensusKey != blockKey {
+			return "", errors.New("block key is not the consensus key of account " + account)
+		}
+		return account, nil
+	}
+	if !ledger.IsAccountKey(account, blockKey) {
+		return "", errors.New("block key is not the key of account " + account)
+	}
+	return account, nil
+}
@@ -0,0 +1,70 @@
+package ledger
+
+import (
+	"packages/address"
+	"testing"
+)
+
+func TestValidatorAccount(t *testing.T) {
+	signers := generateSigners(t, 3)
+	accountKey, consensusKey, otherKey := signers[0].PublicKey(), signers[1].PublicKey(), signers[2].PublicKey()
+	account := address.FromPublicKey(accountKey)
+	ledger := MakeLedger()
+	ledger.Accounts[account] = 10
+
+	if validator, err := ledger.ValidatorAccount("", accountKey); err != nil || validator != account {
+		t.Error("block key not accepted for its own account")
+	}
+	if _, err := ledger.ValidatorAccount(account, accountKey); err != nil {
+		t.Error("account key rejected: " + err.Error())
+	}
+	if _, err := ledger.ValidatorAccount(account, otherKey); err == nil {
+		t.Error("block key of another account accepted")
+	}
+
+	block := signedTransactions(Transaction{Kind: KIND_REGISTER_CONSENSUS_KEY, From: account, Fee: TRANSACTION_FEE, ConsensusKey: consensusKey})
+	if err := ledger.CheckBlock(block, TEST_CHAIN_ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	ledger.ExecuteTransaction(block[0])
+	if _, err := ledger.ValidatorAccount(account, consensusKey); err != nil {
+		t.Error("consensus key rejected: " + err.Error())
+	}
+	// once a consensus key is registered, the account key no longer draws, so the stake is not drawn against twice
+	if _, err := ledger.ValidatorAccount(account, accountKey); err == nil {
+		t.Error("account key accepted after registering a consensus key")
+	}
+	if _, err := ledger.ValidatorAccount(account, otherKey); err == nil {
+		t.Error("other key accepted after registering a consensus key")
+	}
+}
+
+func TestRegisterConsensusKey(t *testing.T) {
+	signers := generateSigners(t, 2)
+	consensusKey := signers[0].PublicKey()
+	ledger := MakeLedger()
+	ledger.Accounts["alice"] = 10
+	ledger.Accounts["bob"] = 10
+	ledger.ConsensusKeys["carol"] = signers[1].PublicKey()
+	register := func(from string, key string) Transaction {
+		return Transaction{Kind: KIND_REGISTER_CONSENSUS_KEY, From: from, Fee: TRANSACTION_FEE, ConsensusKey: key}
+	}
+	withAmount := register("alice", consensusKey)
+	withAmount.To = "bob"
+	withAmount.Amount = 5
+
+	for name, block := range map[string][]SignedTransaction{
+		"invalid key":                        signedTransactions(register("alice", "key")),
+		"key of another account":             signedTransactions(register("alice", signers[1].PublicKey())),
+		"same key for two accounts in block": signedTransactions(register("alice", consensusKey), register("bob", consensusKey)),
+		"amount sent":                        signedTransactions(withAmount),
+		"no funds for the fee":               signedTransactions(register("dave", consensusKey)),
+	} {
+		if err := ledger.CheckBlock(block, TEST_CHAIN_ID, 0); err == nil {
+			t.Error(name + " accepted")
+		}
+	}
+	if err := ledger.CheckBlock(signedTransactions(register("alice", consensusKey)), TEST_CHAIN_ID, 0); err != nil {
+		t.Error("registration rejected: " + err.Error())
+	}
+}
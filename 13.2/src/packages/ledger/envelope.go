@@ -0,0 +1,122 @@
+/**
+A transaction envelope is a JSON file of the form
+
+	{"Format": "transaction-envelope", "Version": 1, "Transaction": <SignedTransaction>}
+
+where the signed transaction has the fields of SignedTransaction. An unsigned transaction has an
+empty Signature and no Signatures; signing fills in PublicKey, Scheme and Signature, or appends
+to Signatures for a multisignature account. The same file can be prepared online, signed on a
+machine holding only the keystore, and submitted to any node.
+**/
+
+package ledger
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"packages/signature"
+	"strconv"
+	"strings"
+)
+
+const ENVELOPE_FORMAT = "transaction-envelope"
+const ENVELOPE_VERSION = 1
+
+/* Transaction envelope struct */
+type TransactionEnvelope struct {
+	Format      string
+	Version     int
+	Transaction SignedTransaction
+}
+
+/* Wrap a transaction in an envelope */
+func MakeEnvelope(signedTransaction SignedTransaction) TransactionEnvelope {
+	signedTransaction.Type = "signedTransaction"
+	return TransactionEnvelope{Format: ENVELOPE_FORMAT, Version: ENVELOPE_VERSION, Transaction: signedTransaction}
+}
+
+/* Check if the transaction of an envelope carries a signature */
+func (envelope TransactionEnvelope) IsSigned() bool {
+	return envelope.Transaction.Signature != "" || len(envelope.Transaction.Signatures) > 0
+}
+
+/* Write an envelope to a file, readable only by its owner */
+func WriteEnvelope(filename string, envelope TransactionEnvelope) error {
+	envelopeBytes, err := json.MarshalIndent(envelope, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, envelopeBytes, 0600)
+}
+
+/* Read an envelope from a file */
+func ReadEnvelope(filename string) (TransactionEnvelope, error) {
+	var envelope TransactionEnvelope
+	envelopeBytes, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return envelope, err
+	}
+	if err := json.Unmarshal(envelopeBytes, &envelope); err != nil {
+		return envelope, err
+	}
+	if envelope.Format != ENVELOPE_FORMAT {
+		return envelope, errors.New(filename + " is not a transaction envelope")
+	}
+	if envelope.Version != ENVELOPE_VERSION {
+		return envelope, errors.New("unsupported transaction envelope version " + strconv.Itoa(envelope.Version))
+	}
+	return envelope, nil
+}
+
+/* Describe a transaction for the user to check before signing it */
+func (transaction Transaction) Describe() string {
+	kind := transaction.Kind
+	if kind == KIND_TRANSFER {
+		kind = "transfer"
+	}
+	description := kind + " " + transaction.ID + " on chain " + transaction.ChainID + ": " + strconv.Itoa(transaction.Amount) + " AU from " + transaction.From + " to " + transaction.To +
+		" with a fee of " + strconv.Itoa(transaction.Fee) + " AU and nonce " + strconv.Itoa(transaction.Nonce)
+	if transaction.ValidUntilSlot != 0 {
+		description += ", valid until slot " + strconv.Itoa(transaction.ValidUntilSlot)
+	}
+	// every field that changes what the transaction does is shown, as the signature covers all of them
+	if transaction.NewKey != "" {
+		description += "\n  new key: " + describeKey(transaction.NewKey)
+	}
+	if transaction.RecoveryKey != "" {
+		description += "\n  recovery key: " + describeKey(transaction.RecoveryKey)
+	}
+	if transaction.ConsensusKey != "" {
+		description += "\n  consensus key: " + describeKey(transaction.ConsensusKey)
+	}
+	if guardians := transaction.Guardians; guardians != nil {
+		description += "\n  guardians: " + strconv.Itoa(guardians.Threshold) + " of " + strings.Join(guardians.Guardians, ", ")
+	}
+	if policy := transaction.Multisig; policy != nil {
+		keys := make([]string, len(policy.PublicKeys))
+		for i, publicKey := range policy.PublicKeys {
+			keys[i] = describeKey(publicKey)
+		}
+		description += "\n  multisignature policy: " + strconv.Itoa(policy.Threshold) + " of " + strings.Join(keys, ", ")
+	}
+	if issuer := transaction.VoucherIssuer; issuer != nil {
+		description += "\n  voucher issuer key: " + describeKey(issuer.PublicKey) + ", denomination " + strconv.Itoa(issuer.Denomination) + " AU"
+	}
+	if voucher := transaction.Voucher; voucher != nil {
+		description += "\n  voucher of issuer " + voucher.Issuer + " with serial " + voucher.Serial
+	}
+	if memo := transaction.Memo; memo != nil {
+		description += "\n  memo encrypted to " + memo.Recipient + " (" + memo.Scheme + ")"
+	}
+	return description
+}
+
+/* Describe a public key by its fingerprint, or show it as given if it cannot be parsed */
+func describeKey(publicKey string) string {
+	fingerprint, err := signature.Fingerprint(publicKey)
+	if err != nil {
+		return "invalid key " + strconv.Quote(publicKey)
+	}
+	return signature.KeyType(publicKey) + " key " + fingerprint
+}
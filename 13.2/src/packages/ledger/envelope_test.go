@@ -0,0 +1,37 @@
+package ledger
+
+import (
+	"packages/encryption"
+	"packages/signature"
+	"strings"
+	"testing"
+)
+
+/* The description shown before signing must include every payload field of the transaction */
+func TestDescribe(t *testing.T) {
+	keys := publicKeys(generateSigners(t, 2))
+	fingerprint, err := signature.Fingerprint(keys[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, test := range map[string]struct {
+		transaction Transaction
+		shown       []string
+	}{
+		"new key":        {Transaction{Kind: KIND_ROTATE_KEY, NewKey: keys[0]}, []string{"new key", fingerprint}},
+		"recovery key":   {Transaction{Kind: KIND_REGISTER_RECOVERY_KEY, RecoveryKey: keys[0]}, []string{"recovery key", fingerprint}},
+		"consensus key":  {Transaction{Kind: KIND_REGISTER_CONSENSUS_KEY, ConsensusKey: keys[0]}, []string{"consensus key", fingerprint}},
+		"guardians":      {Transaction{Kind: KIND_SET_GUARDIANS, Guardians: &GuardianPolicy{Threshold: 2, Guardians: []string{"guardian1", "guardian2"}}}, []string{"2 of guardian1, guardian2"}},
+		"multisig":       {Transaction{Kind: KIND_CREATE_MULTISIG, Multisig: &MultisigPolicy{Threshold: 1, PublicKeys: keys}}, []string{"1 of", fingerprint}},
+		"voucher issuer": {Transaction{Kind: KIND_REGISTER_VOUCHER_ISSUER, VoucherIssuer: &VoucherIssuer{PublicKey: "key", Denomination: 7}}, []string{`invalid key "key"`, "denomination 7 AU"}},
+		"voucher":        {Transaction{Kind: KIND_REDEEM_VOUCHER, Voucher: &Voucher{Issuer: "issuer", Serial: "0a0b"}}, []string{"issuer issuer", "serial 0a0b"}},
+		"memo":           {Transaction{Memo: &encryption.Envelope{Recipient: "receiver", Scheme: encryption.SCHEME_X25519}}, []string{"memo encrypted to receiver"}},
+	} {
+		description := test.transaction.Describe()
+		for _, shown := range test.shown {
+			if !strings.Contains(description, shown) {
+				t.Errorf("%v: %q does not show %q", name, description, shown)
+			}
+		}
+	}
+}
@@ -0,0 +1,189 @@
+/**
+An account nominates guardian accounts and a threshold. When Threshold guardians have approved
+the same new key, the recovery is pending for RECOVERY_TIME_LOCK_SLOTS slots, during which the
+current key of the account can cancel it. Once the time-lock has passed, the key is replaced at
+the start of the next block.
+**/
+
+package ledger
+
+import (
+	"errors"
+	"packages/address"
+	"packages/signature"
+	"packages/vrf"
+	"sort"
+	"strconv"
+)
+
+const KIND_SET_GUARDIANS = "setGuardians"
+const KIND_APPROVE_RECOVERY = "approveRecovery"
+const KIND_CANCEL_RECOVERY = "cancelRecovery"
+
+const MAX_GUARDIANS = 16
+const RECOVERY_TIME_LOCK_SLOTS = 200
+
+/* Guardian policy struct, set by the account it may recover */
+type GuardianPolicy struct {
+	Threshold int      // Number of guardians needed to recover the account
+	Guardians []string // Accounts of the guardians
+}
+
+/* Pending recovery struct, for an account whose guardians have approved a new key */
+type PendingRecovery struct {
+	NewKey    string // Key replacing the key of the account
+	ReadySlot int    // First slot in which the key is replaced
+}
+
+/* Check that a policy is well-formed for an account */
+func (policy GuardianPolicy) Validate(account string) error {
+	if len(policy.Guardians) < 1 || len(policy.Guardians) > MAX_GUARDIANS {
+		return errors.New("account must have between 1 and " + strconv.Itoa(MAX_GUARDIANS) + " guardians")
+	}
+	if policy.Threshold < 1 || policy.Threshold > len(policy.Guardians) {
+		return errors.New("guardian threshold must be between 1 and the number of guardians")
+	}
+	seen := make(map[string]bool)
+	for _, guardian := range policy.Guardians {
+		if err := address.Validate(guardian); err != nil {
+			return errors.New(guardian + " is not a valid address: " + err.Error())
+		}
+		if guardian == account {
+			return errors.New("account cannot be its own guardian")
+		}
+		if seen[guardian] {
+			return errors.New("guardian policy lists an account twice")
+		}
+		seen[guardian] = true
+	}
+	return nil
+}
+
+/* Check if an account is one of the guardians of a policy */
+func (policy GuardianPolicy) IsGuardian(account string) bool {
+	for _, guardian := range policy.Guardians {
+		if guardian == account {
+			return true
+		}
+	}
+	return false
+}
+
+/* Get the guardian policy of an account */
+func (ledger *Ledger) GetGuardians(account string) (GuardianPolicy, bool) {
+	ledger.LedgerLock.Lock()
+	defer ledger.LedgerLock.Unlock()
+	policy, found := ledger.Guardians[account]
+	return policy, found
+}
+
+/* Get the recovery of an account that is waiting for its time-lock */
+func (ledger *Ledger) GetPendingRecovery(account string) (PendingRecovery, bool) {
+	ledger.LedgerLock.Lock()
+	defer ledger.LedgerLock.Unlock()
+	recovery, found := ledger.PendingRecoveries[account]
+	return recovery, found
+}
+
+/* Start executing the block of a validated slot, replacing the keys of the accounts whose recovery time-lock has passed. Returns the recovered accounts */
+func (ledger *Ledger) StartSlot(slot int) []string {
+	ledger.LedgerLock.Lock()
+	defer ledger.LedgerLock.Unlock()
+	// new time-locks start from this slot, so it must not be ahead of the clock
+	ledger.Slot = slot
+	recovered := make([]string, 0)
+	for account, recovery := range ledger.PendingRecoveries {
+		if recovery.ReadySlot <= slot {
+			ledger.AccountKeys[account] = recovery.NewKey
+			delete(ledger.PendingRecoveries, account)
+			delete(ledger.RecoveryApprovals, account)
+			recovered = append(recovered, account)
+		}
+	}
+	sort.Strings(recovered)
+	return recovered
+}
+
+func (ledger *Ledger) checkSetGuardians(transaction Transaction) error {
+	if transaction.Guardians == nil {
+		return errors.New("transaction carries no guardian policy")
+	}
+	if err := transaction.Guardians.Validate(transaction.From); err != nil {
+		return err
+	}
+	if _, found := ledger.GetMultisigPolicy(transaction.From); found {
+		return errors.New("a multisignature account cannot have guardians")
+	}
+	return ledger.checkRecoveryFee(transaction)
+}
+
+func (ledger *Ledger) checkApproveRecovery(transaction Transaction) error {
+	policy, found := ledger.GetGuardians(transaction.To)
+	if !found {
+		return errors.New("account " + transaction.To + " has no guardians")
+	}
+	if !policy.IsGuardian(transaction.From) {
+		return errors.New("sender is not a guardian of account " + transaction.To)
+	}
+	if _, err := signature.ParseVerifier(transaction.NewKey); err != nil {
+		return errors.New("transaction does not carry a valid public key")
+	}
+	if ledger.IsAccountKey(transaction.To, transaction.NewKey) {
+		return errors.New("new key is already the key of the account")
+	}
+	if err := vrf.ValidateKey(transaction.NewKey); err != nil {
+		return errors.New("new key cannot draw in the lottery: " + err.Error())
+	}
+	if recovery, pending := ledger.GetPendingRecovery(transaction.To); pending && recovery.NewKey != transaction.NewKey {
+		return errors.New("a recovery of account " + transaction.To + " to another key is already pending")
+	}
+	return ledger.checkRecoveryFee(transaction)
+}
+
+func (ledger *Ledger) checkCancelRecovery(transaction Transaction) error {
+	if transaction.To != transaction.From {
+		return errors.New("an account can only cancel its own recovery")
+	}
+	ledger.LedgerLock.Lock()
+	approvals := len(ledger.RecoveryApprovals[transaction.From])
+	ledger.LedgerLock.Unlock()
+	if approvals == 0 {
+		return errors.New("no recovery of account " + transaction.From + " is in progress")
+	}
+	return ledger.checkRecoveryFee(transaction)
+}
+
+/* Checks shared by the transactions of a recovery */
+func (ledger *Ledger) checkRecoveryFee(transaction Transaction) error {
+	if transaction.Amount != 0 {
+		return errors.New("recovery transaction must not send an amount")
+	}
+	if transaction.Fee > ledger.GetBalance(transaction.From) {
+		return errors.New("insufficient funds in the sender's account")
+	}
+	return nil
+}
+
+/* Record the approval of a guardian, starting the time-lock once enough guardians approve the same key. The ledger must be locked */
+func (ledger *Ledger) approveRecovery(transaction Transaction) {
+	approvals, found := ledger.RecoveryApprovals[transaction.To]
+	if !found {
+		approvals = make(map[string]string)
+		ledger.RecoveryApprovals[transaction.To] = approvals
+	}
+	approvals[transaction.From] = transaction.NewKey
+	if _, pending := ledger.PendingRecoveries[transaction.To]; pending {
+		return
+	}
+	// only guardians still in the policy count, as it may have changed since they approved
+	policy := ledger.Guardians[transaction.To]
+	approved := 0
+	for guardian, newKey := range approvals {
+		if newKey == transaction.NewKey && policy.IsGuardian(guardian) {
+			approved++
+		}
+	}
+	if approved >= policy.Threshold {
+		ledger.PendingRecoveries[transaction.To] = PendingRecovery{NewKey: transaction.NewKey, ReadySlot: ledger.Slot + RECOVERY_TIME_LOCK_SLOTS}
+	}
+}
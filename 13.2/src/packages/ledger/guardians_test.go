@@ -0,0 +1,185 @@
+package ledger
+
+import (
+	"packages/address"
+	"testing"
+)
+
+/* Ledger with an account guarded by three guardians, two of which must approve a recovery */
+func guardedLedger(t *testing.T) (*Ledger, string, []string) {
+	signers := generateSigners(t, 4)
+	account := address.FromPublicKey(signers[0].PublicKey())
+	guardians := make([]string, 3)
+	for i := range guardians {
+		guardians[i] = address.FromPublicKey(signers[i+1].PublicKey())
+	}
+	ledger := MakeLedger()
+	ledger.Accounts[account] = 10
+	for _, guardian := range guardians {
+		ledger.Accounts[guardian] = 10
+	}
+	block := signedTransactions(Transaction{Kind: KIND_SET_GUARDIANS, From: account, Fee: TRANSACTION_FEE, Guardians: &GuardianPolicy{Threshold: 2, Guardians: guardians}})
+	if err := ledger.CheckBlock(block, TEST_CHAIN_ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	ledger.ExecuteTransaction(block[0])
+	return ledger, account, guardians
+}
+
+func approval(guardian string, account string, newKey string, nonce int) Transaction {
+	return Transaction{Kind: KIND_APPROVE_RECOVERY, From: guardian, To: account, Nonce: nonce, Fee: TRANSACTION_FEE, NewKey: newKey}
+}
+
+/* Execute a block after checking it in its slot, as the peer does for a block of a validated slot */
+func executeBlock(t *testing.T, ledger *Ledger, slot int, transactions ...Transaction) {
+	block := signedTransactions(transactions...)
+	if err := ledger.CheckBlock(block, TEST_CHAIN_ID, slot); err != nil {
+		t.Fatal(err)
+	}
+	ledger.StartSlot(slot)
+	for _, signedTransaction := range block {
+		ledger.ExecuteTransaction(signedTransaction)
+	}
+}
+
+func TestGuardianPolicyValidate(t *testing.T) {
+	signers := generateSigners(t, 3)
+	account := address.FromPublicKey(signers[0].PublicKey())
+	guardian1 := address.FromPublicKey(signers[1].PublicKey())
+	guardian2 := address.FromPublicKey(signers[2].PublicKey())
+	if err := (GuardianPolicy{Threshold: 1, Guardians: []string{guardian1, guardian2}}).Validate(account); err != nil {
+		t.Error("valid policy rejected: " + err.Error())
+	}
+	for name, policy := range map[string]GuardianPolicy{
+		"no guardians":        {Threshold: 1},
+		"zero threshold":      {Threshold: 0, Guardians: []string{guardian1}},
+		"threshold too large": {Threshold: 2, Guardians: []string{guardian1}},
+		"own guardian":        {Threshold: 1, Guardians: []string{account}},
+		"duplicate guardian":  {Threshold: 1, Guardians: []string{guardian1, guardian1}},
+		"invalid address":     {Threshold: 1, Guardians: []string{"guardian"}},
+	} {
+		if policy.Validate(account) == nil {
+			t.Error(name + " accepted")
+		}
+	}
+}
+
+/* The key is replaced only once enough guardians approved it and the time-lock has passed */
+func TestRecoveryTimeLock(t *testing.T) {
+	ledger, account, guardians := guardedLedger(t)
+	newKey := generateSigners(t, 1)[0].PublicKey()
+
+	executeBlock(t, ledger, 100, approval(guardians[0], account, newKey, 0))
+	if _, pending := ledger.GetPendingRecovery(account); pending {
+		t.Fatal("recovery started with fewer approvals than the threshold")
+	}
+	// the same guardian approving again does not count twice
+	executeBlock(t, ledger, 101, approval(guardians[0], account, newKey, 1))
+	if _, pending := ledger.GetPendingRecovery(account); pending {
+		t.Fatal("repeated approval of a guardian counted twice")
+	}
+	executeBlock(t, ledger, 102, approval(guardians[1], account, newKey, 0))
+	recovery, pending := ledger.GetPendingRecovery(account)
+	if !pending || recovery.NewKey != newKey || recovery.ReadySlot != 102+RECOVERY_TIME_LOCK_SLOTS {
+		t.Fatal("recovery not started once the threshold is reached")
+	}
+
+	if recovered := ledger.StartSlot(recovery.ReadySlot - 1); len(recovered) != 0 || ledger.IsAccountKey(account, newKey) {
+		t.Fatal("key replaced before the time-lock passed")
+	}
+	if recovered := ledger.StartSlot(recovery.ReadySlot); len(recovered) != 1 || recovered[0] != account || !ledger.IsAccountKey(account, newKey) {
+		t.Fatal("key not replaced once the time-lock passed")
+	}
+	if _, pending := ledger.GetPendingRecovery(account); pending {
+		t.Error("recovery still pending after replacing the key")
+	}
+}
+
+/* The account can cancel a recovery with its current key while the time-lock runs */
+func TestCancelRecovery(t *testing.T) {
+	ledger, account, guardians := guardedLedger(t)
+	newKey := generateSigners(t, 1)[0].PublicKey()
+	executeBlock(t, ledger, 100, approval(guardians[0], account, newKey, 0), approval(guardians[1], account, newKey, 0))
+	recovery, pending := ledger.GetPendingRecovery(account)
+	if !pending {
+		t.Fatal("recovery not started")
+	}
+	executeBlock(t, ledger, 101, Transaction{Kind: KIND_CANCEL_RECOVERY, From: account, To: account, Nonce: 1, Fee: TRANSACTION_FEE})
+	if _, pending := ledger.GetPendingRecovery(account); pending {
+		t.Fatal("recovery still pending after cancelling it")
+	}
+	if ledger.StartSlot(recovery.ReadySlot); ledger.IsAccountKey(account, newKey) {
+		t.Error("cancelled recovery replaced the key")
+	}
+	// earlier approvals no longer count
+	executeBlock(t, ledger, recovery.ReadySlot+1, approval(guardians[2], account, newKey, 0))
+	if _, pending := ledger.GetPendingRecovery(account); pending {
+		t.Error("approval given before the cancellation counted")
+	}
+}
+
+func TestApproveRecoveryRejected(t *testing.T) {
+	ledger, account, guardians := guardedLedger(t)
+	keys := publicKeys(generateSigners(t, 2))
+	executeBlock(t, ledger, 100, approval(guardians[0], account, keys[0], 0), approval(guardians[1], account, keys[0], 0))
+	ledger.Accounts["stranger"] = 10
+
+	withAmount := approval(guardians[2], account, keys[0], 0)
+	withAmount.Amount = 5
+	for name, block := range map[string][]SignedTransaction{
+		"approval by a non-guardian":        signedTransactions(approval("stranger", account, keys[0], 0)),
+		"approval of another pending key":   signedTransactions(approval(guardians[2], account, keys[1], 0)),
+		"approval of an unguarded account":  signedTransactions(approval(guardians[2], guardians[0], keys[1], 0)),
+		"approval of an invalid key":        signedTransactions(approval(guardians[2], account, "key", 0)),
+		"approval with an amount":           signedTransactions(withAmount),
+		"cancellation of another account":   signedTransactions(Transaction{Kind: KIND_CANCEL_RECOVERY, From: guardians[2], To: account, Fee: TRANSACTION_FEE}),
+		"cancellation without any recovery": signedTransactions(Transaction{Kind: KIND_CANCEL_RECOVERY, From: guardians[2], To: guardians[2], Fee: TRANSACTION_FEE}),
+	} {
+		if err := ledger.CheckBlock(block, TEST_CHAIN_ID, ledger.GetSlot()); err == nil {
+			t.Error(name + " accepted")
+		}
+	}
+}
+
+/* The time-lock of a recovery starts from the slot of the block approving it, which the peer validated against its clock */
+func TestRecoveryReadySlot(t *testing.T) {
+	ledger, account, guardians := guardedLedger(t)
+	newKey := generateSigners(t, 1)[0].PublicKey()
+	executeBlock(t, ledger, 500, approval(guardians[0], account, newKey, 0), approval(guardians[1], account, newKey, 0))
+	if ledger.GetSlot() != 500 {
+		t.Error("slot of the executed block not recorded")
+	}
+	if recovery, _ := ledger.GetPendingRecovery(account); recovery.ReadySlot != 500+RECOVERY_TIME_LOCK_SLOTS {
+		t.Errorf("recovery ready in slot %v", recovery.ReadySlot)
+	}
+}
+
+/* The block of the slot in which the time-lock ends is checked with the recovered key, not the replaced one */
+func TestRecoveredKeySignsReadySlotBlock(t *testing.T) {
+	ledger, account, guardians := guardedLedger(t)
+	newKey := generateSigners(t, 1)[0].PublicKey()
+	executeBlock(t, ledger, 100, approval(guardians[0], account, newKey, 0), approval(guardians[1], account, newKey, 0))
+	recovery, _ := ledger.GetPendingRecovery(account)
+
+	transfer := Transaction{From: account, To: guardians[0], Amount: 1, Nonce: 1, Fee: TRANSACTION_FEE}
+	state := ledger.SlotState(recovery.ReadySlot)
+	if state.CheckSigningKey(transfer, newKey) != nil {
+		t.Error("recovered key cannot sign in the block of the slot the time-lock ends")
+	}
+	// the recovered key replaces the key derived from the address, which then no longer signs
+	if accountKey, rotated := state.GetAccountKey(account); !rotated || accountKey != newKey {
+		t.Error("replaced key can still sign in the block of the slot the time-lock ends")
+	}
+	cancel := Transaction{Kind: KIND_CANCEL_RECOVERY, From: account, To: account, Nonce: 1, Fee: TRANSACTION_FEE}
+	if err := ledger.CheckBlock(signedTransactions(cancel), TEST_CHAIN_ID, recovery.ReadySlot-1); err != nil {
+		t.Error("cancellation rejected while the time-lock runs: " + err.Error())
+	}
+	if err := ledger.CheckBlock(signedTransactions(cancel), TEST_CHAIN_ID, recovery.ReadySlot); err == nil {
+		t.Error("cancellation accepted in the slot the recovery completes")
+	}
+
+	executeBlock(t, ledger, recovery.ReadySlot, transfer)
+	if !ledger.IsAccountKey(account, newKey) || ledger.GetNonce(account) != 2 {
+		t.Error("block of the slot the time-lock ends not executed with the recovered key")
+	}
+}
@@ -8,48 +8,299 @@ DESCRIPTION: Distributed transaction system implemented as structured P2P floodi
 package ledger
 
 import (
+	"errors"
 	"fmt"
+	"packages/encryption"
 	"strconv"
 	"sync"
 )
 
+const KIND_TRANSFER = ""
 const TRANSACTION_FEE = 1
+const MIN_FEE_INCREMENT = 1 // default minimum fee increase for replacing a pending transaction
 
 /* Signed transaction struct */
 type SignedTransaction struct {
 	Type        string      // Signed transaction
 	Transaction Transaction //Transaction object of a signed transaction
+	PublicKey   string      // Public key of the sender, needed to verify the signature
+	Scheme      string      // Signature scheme of the signature
 	Signature   string      // Signature of the transaction
+
+	Signatures []MultisigSignature `json:",omitempty"` // Signatures of the cosigners, if the sender is a multisignature account
 }
 
 /* Transaction struct */
 type Transaction struct {
-	ID     string // ID of the transaction
-	From   string // Sender of the transaction (public key)
-	To     string // Receiver of the transaction (public key)
-	Amount int    // Amount to transfer
+	ID      string // ID of the transaction
+	ChainID string // Chain ID of the network the transaction is valid on
+	From    string // Sender of the transaction (account address)
+	To      string // Receiver of the transaction (account address)
+	Amount  int    // Amount to transfer
+	Nonce   int    // Sequence number of the transaction among the sender's transactions
+	Fee     int    // Fee paid to the creator of the block containing the transaction
+
+	ValidUntilSlot int                  // Last slot in which the transaction may be included in a block (0 = no expiry)
+	Memo           *encryption.Envelope `json:",omitempty"` // Memo encrypted to the receiver
+
+	Kind          string          `json:",omitempty"` // Kind of the transaction (empty for a transfer)
+	VoucherIssuer *VoucherIssuer  `json:",omitempty"` // Voucher issuer registered by the sender
+	Voucher       *Voucher        `json:",omitempty"` // Voucher redeemed for the receiver
+	Multisig      *MultisigPolicy `json:",omitempty"` // Policy of the multisignature account created by the transaction
+	ConsensusKey  string          `json:",omitempty"` // Consensus key authorised by the sender
+	NewKey        string          `json:",omitempty"` // Key replacing the sender's key
+	RecoveryKey   string          `json:",omitempty"` // Recovery key registered by the sender
+	Guardians     *GuardianPolicy `json:",omitempty"` // Guardians nominated by the sender
 }
 
 /* Ledger struct */
 type Ledger struct {
 	Type       string
-	Accounts   map[string]int
+	Accounts   map[string]int // account address -> balance
+	Nonces     map[string]int // account -> nonce expected in the next transaction
 	LedgerLock sync.Mutex
+
+	VoucherIssuers map[string]VoucherIssuer // issuer account -> voucher issuer
+	SpentSerials   map[string]bool          // issuer account and serial of redeemed vouchers
+
+	MultisigAccounts map[string]MultisigPolicy // multisignature account -> policy
+	ConsensusKeys    map[string]string         // validator account -> consensus public key
+	AccountKeys      map[string]string         // rotated account -> current public key
+	RecoveryKeys     map[string]string         // account -> recovery public key
+
+	Guardians         map[string]GuardianPolicy    // account -> guardians that may recover it
+	RecoveryApprovals map[string]map[string]string // account -> guardian -> approved key
+	PendingRecoveries map[string]PendingRecovery   // account -> recovery waiting for its time-lock
+	Slot              int                          // slot of the block being executed
+}
+
+/* Check if a transaction can no longer be included in a block of the given slot */
+func (transaction Transaction) IsExpired(slot int) bool {
+	return transaction.ValidUntilSlot != 0 && slot > transaction.ValidUntilSlot
 }
 
 /* Ledger constructor */
 func MakeLedger() *Ledger {
 	ledger := new(Ledger)
 	ledger.Accounts = make(map[string]int)
+	ledger.Nonces = make(map[string]int)
+	ledger.VoucherIssuers = make(map[string]VoucherIssuer)
+	ledger.SpentSerials = make(map[string]bool)
+	ledger.MultisigAccounts = make(map[string]MultisigPolicy)
+	ledger.ConsensusKeys = make(map[string]string)
+	ledger.AccountKeys = make(map[string]string)
+	ledger.RecoveryKeys = make(map[string]string)
+	ledger.Guardians = make(map[string]GuardianPolicy)
+	ledger.RecoveryApprovals = make(map[string]map[string]string)
+	ledger.PendingRecoveries = make(map[string]PendingRecovery)
 	return ledger
 }
 
+/* Copy the ledger, so transactions can be tried out without changing it */
+func (ledger *Ledger) Copy() *Ledger {
+	ledger.LedgerLock.Lock()
+	defer ledger.LedgerLock.Unlock()
+	working := MakeLedger()
+	working.Type = ledger.Type
+	working.Slot = ledger.Slot
+	for account, balance := range ledger.Accounts {
+		working.Accounts[account] = balance
+	}
+	for account, nonce := range ledger.Nonces {
+		working.Nonces[account] = nonce
+	}
+	for account, issuer := range ledger.VoucherIssuers {
+		working.VoucherIssuers[account] = issuer
+	}
+	for serial, spent := range ledger.SpentSerials {
+		working.SpentSerials[serial] = spent
+	}
+	for account, policy := range ledger.MultisigAccounts {
+		working.MultisigAccounts[account] = policy
+	}
+	for account, consensusKey := range ledger.ConsensusKeys {
+		working.ConsensusKeys[account] = consensusKey
+	}
+	for account, accountKey := range ledger.AccountKeys {
+		working.AccountKeys[account] = accountKey
+	}
+	for account, recoveryKey := range ledger.RecoveryKeys {
+		working.RecoveryKeys[account] = recoveryKey
+	}
+	for account, policy := range ledger.Guardians {
+		working.Guardians[account] = policy
+	}
+	for account, approvals := range ledger.RecoveryApprovals {
+		working.RecoveryApprovals[account] = make(map[string]string)
+		for guardian, newKey := range approvals {
+			working.RecoveryApprovals[account][guardian] = newKey
+		}
+	}
+	for account, recovery := range ledger.PendingRecoveries {
+		working.PendingRecoveries[account] = recovery
+	}
+	return working
+}
+
+/* Copy of the ledger in which the block of a slot is checked, with the recoveries whose time-lock passed by the slot completed as they are when the block is executed */
+func (ledger *Ledger) SlotState(slot int) *Ledger {
+	working := ledger.Copy()
+	working.StartSlot(slot)
+	return working
+}
+
+/* Check the transactions of the block of a slot in order, each against the ledger as left by the transactions before it */
+func (ledger *Ledger) CheckBlock(transactions []SignedTransaction, chainID string, slot int) error {
+	working := ledger.SlotState(slot)
+	for _, signedTransaction := range transactions {
+		if err := working.checkNextTransaction(signedTransaction.Transaction, chainID); err != nil {
+			return errors.New("transaction " + signedTransaction.Transaction.ID + ": " + err.Error())
+		}
+		working.ExecuteTransaction(signedTransaction)
+	}
+	return nil
+}
+
+/* Keep the transactions of a list that pass the checks of the block of a slot in order, dropping the others */
+func (ledger *Ledger) SelectValid(transactions []SignedTransaction, chainID string, slot int) []SignedTransaction {
+	working := ledger.SlotState(slot)
+	valid := make([]SignedTransaction, 0, len(transactions))
+	for _, signedTransaction := range transactions {
+		if working.checkNextTransaction(signedTransaction.Transaction, chainID) != nil {
+			continue
+		}
+		working.ExecuteTransaction(signedTransaction)
+		valid = append(valid, signedTransaction)
+	}
+	return valid
+}
+
+/* Check a transaction of a block, which must also continue the nonce sequence of its sender */
+func (ledger *Ledger) checkNextTransaction(transaction Transaction, chainID string) error {
+	if nonce := ledger.GetNonce(transaction.From); transaction.Nonce != nonce {
+		return errors.New("nonce " + strconv.Itoa(transaction.Nonce) + " does not continue the sender's nonce sequence at " + strconv.Itoa(nonce))
+	}
+	return ledger.CheckTransaction(transaction, chainID)
+}
+
+/* Check a transaction against the ledger, depending on its kind */
+func (ledger *Ledger) CheckTransaction(transaction Transaction, chainID string) error {
+	switch transaction.Kind {
+	case KIND_TRANSFER:
+		if transaction.Amount < 1 {
+			return errors.New("transaction must send at least 1 AU to be valid")
+		}
+		if transaction.Amount+transaction.Fee > ledger.GetBalance(transaction.From) {
+			return errors.New("insufficient funds in the sender's account")
+		}
+		return nil
+	case KIND_REGISTER_VOUCHER_ISSUER:
+		return ledger.checkVoucherIssuer(transaction)
+	case KIND_REDEEM_VOUCHER:
+		return ledger.CheckRedemption(transaction, chainID)
+	case KIND_CREATE_MULTISIG:
+		return ledger.checkCreateMultisig(transaction)
+	case KIND_REGISTER_CONSENSUS_KEY:
+		return ledger.checkConsensusKey(transaction)
+	case KIND_ROTATE_KEY:
+		return ledger.checkRotateKey(transaction)
+	case KIND_REGISTER_RECOVERY_KEY:
+		return ledger.checkRecoveryKey(transaction)
+	case KIND_SET_GUARDIANS:
+		return ledger.checkSetGuardians(transaction)
+	case KIND_APPROVE_RECOVERY:
+		return ledger.checkApproveRecovery(transaction)
+	case KIND_CANCEL_RECOVERY:
+		return ledger.checkCancelRecovery(transaction)
+	default:
+		return errors.New("unknown transaction kind " + transaction.Kind)
+	}
+}
+
 /* Transaction method */
 func (ledger *Ledger) ExecuteTransaction(signedTransaction SignedTransaction) {
 	ledger.LedgerLock.Lock()
-	ledger.Accounts[signedTransaction.Transaction.From] -= signedTransaction.Transaction.Amount
-	ledger.Accounts[signedTransaction.Transaction.To] += signedTransaction.Transaction.Amount //todo: add the transaction fee
+	transaction := signedTransaction.Transaction
+	switch transaction.Kind {
+	case KIND_REGISTER_VOUCHER_ISSUER:
+		ledger.Accounts[transaction.From] -= transaction.Fee
+		ledger.VoucherIssuers[transaction.From] = *transaction.VoucherIssuer
+	case KIND_REDEEM_VOUCHER:
+		// the denomination is paid by the issuer, and the fee out of the denomination
+		ledger.Accounts[transaction.Voucher.Issuer] -= transaction.Amount
+		ledger.Accounts[transaction.To] += transaction.Amount - transaction.Fee
+		ledger.SpentSerials[voucherSerialKey(*transaction.Voucher)] = true
+	case KIND_CREATE_MULTISIG:
+		ledger.Accounts[transaction.From] -= transaction.Amount + transaction.Fee
+		ledger.Accounts[transaction.To] += transaction.Amount
+		ledger.MultisigAccounts[transaction.To] = *transaction.Multisig
+	case KIND_REGISTER_CONSENSUS_KEY:
+		ledger.Accounts[transaction.From] -= transaction.Fee
+		ledger.ConsensusKeys[transaction.From] = transaction.ConsensusKey
+	case KIND_ROTATE_KEY:
+		ledger.Accounts[transaction.From] -= transaction.Fee
+		ledger.AccountKeys[transaction.From] = transaction.NewKey
+	case KIND_REGISTER_RECOVERY_KEY:
+		ledger.Accounts[transaction.From] -= transaction.Fee
+		ledger.RecoveryKeys[transaction.From] = transaction.RecoveryKey
+	case KIND_SET_GUARDIANS:
+		// approvals given under the previous guardians no longer count
+		ledger.Accounts[transaction.From] -= transaction.Fee
+		ledger.Guardians[transaction.From] = *transaction.Guardians
+		delete(ledger.RecoveryApprovals, transaction.From)
+		delete(ledger.PendingRecoveries, transaction.From)
+	case KIND_APPROVE_RECOVERY:
+		ledger.Accounts[transaction.From] -= transaction.Fee
+		ledger.approveRecovery(transaction)
+	case KIND_CANCEL_RECOVERY:
+		ledger.Accounts[transaction.From] -= transaction.Fee
+		delete(ledger.RecoveryApprovals, transaction.From)
+		delete(ledger.PendingRecoveries, transaction.From)
+	default:
+		ledger.Accounts[transaction.From] -= transaction.Amount + transaction.Fee
+		ledger.Accounts[transaction.To] += transaction.Amount
+	}
+	if transaction.Nonce >= ledger.Nonces[transaction.From] {
+		ledger.Nonces[transaction.From] = transaction.Nonce + 1
+	}
+	defer ledger.LedgerLock.Unlock()
+}
+
+/* Get the balance of an account */
+func (ledger *Ledger) GetBalance(account string) int {
+	ledger.LedgerLock.Lock()
+	defer ledger.LedgerLock.Unlock()
+	return ledger.Accounts[account]
+}
+
+/* Get the nonce expected in the next transaction of an account */
+func (ledger *Ledger) GetNonce(account string) int {
+	ledger.LedgerLock.Lock()
+	defer ledger.LedgerLock.Unlock()
+	return ledger.Nonces[account]
+}
+
+/* Get the slot of the last block executed */
+func (ledger *Ledger) GetSlot() int {
+	ledger.LedgerLock.Lock()
 	defer ledger.LedgerLock.Unlock()
+	return ledger.Slot
+}
+
+/* Sum the fees paid by a list of transactions */
+func TotalFees(transactions []SignedTransaction) int {
+	fees := 0
+	for _, transaction := range transactions {
+		fees += transaction.Transaction.Fee
+	}
+	return fees
+}
+
+/* Check if a transaction may replace a pending transaction with the same sender and nonce */
+func CanReplace(pending Transaction, replacement Transaction, minFeeIncrement int) bool {
+	return pending.From == replacement.From &&
+		pending.Nonce == replacement.Nonce &&
+		replacement.Fee >= pending.Fee+minFeeIncrement
 }
 
 /* Print ledger method */
@@ -0,0 +1,61 @@
+package ledger
+
+import "testing"
+
+func TestCheckBlock(t *testing.T) {
+	ledger := MakeLedger()
+	ledger.Accounts["alice"] = 10
+	block := signedTransactions(
+		Transaction{From: "alice", To: "bob", Amount: 5, Nonce: 0, Fee: TRANSACTION_FEE},
+		Transaction{From: "bob", To: "carol", Amount: 4, Nonce: 0, Fee: TRANSACTION_FEE}, // spends what alice sent in the block
+		Transaction{From: "alice", To: "carol", Amount: 3, Nonce: 1, Fee: TRANSACTION_FEE},
+	)
+	if err := ledger.CheckBlock(block, TEST_CHAIN_ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	if ledger.GetBalance("alice") != 10 || ledger.GetNonce("alice") != 0 {
+		t.Error("checking a block changed the ledger")
+	}
+}
+
+/* Each transaction of a block is checked against the ledger as left by the transactions before it */
+func TestCheckBlockRejectsInvalidBlocks(t *testing.T) {
+	ledger := MakeLedger()
+	ledger.Accounts["alice"] = 10
+	ledger.Nonces["alice"] = 2
+	transfer := func(amount int, nonce int) Transaction {
+		return Transaction{From: "alice", To: "bob", Amount: amount, Nonce: nonce, Fee: TRANSACTION_FEE}
+	}
+	for name, block := range map[string][]SignedTransaction{
+		"overdraw across the block": signedTransactions(transfer(5, 2), transfer(5, 3)),
+		"replayed nonce":            signedTransactions(transfer(1, 1)),
+		"nonce gap":                 signedTransactions(transfer(1, 3)),
+		"repeated nonce":            signedTransactions(transfer(1, 2), transfer(1, 2)),
+		"zero amount":               signedTransactions(transfer(0, 2)),
+		"unknown kind":              signedTransactions(Transaction{Kind: "mint", From: "alice", To: "alice", Amount: 100, Nonce: 2}),
+		"valid then invalid":        signedTransactions(transfer(1, 2), transfer(100, 3)),
+	} {
+		if err := ledger.CheckBlock(block, TEST_CHAIN_ID, 0); err == nil {
+			t.Error(name + " accepted")
+		}
+	}
+}
+
+/* The transactions selected for a block must pass CheckBlock */
+func TestSelectValid(t *testing.T) {
+	ledger := MakeLedger()
+	ledger.Accounts["alice"] = 10
+	pending := signedTransactions(
+		Transaction{From: "alice", To: "bob", Amount: 5, Nonce: 0, Fee: TRANSACTION_FEE},
+		Transaction{From: "alice", To: "bob", Amount: 5, Nonce: 1, Fee: TRANSACTION_FEE}, // overdraws
+		Transaction{From: "alice", To: "bob", Amount: 1, Nonce: 3, Fee: TRANSACTION_FEE}, // nonce gap
+		Transaction{From: "alice", To: "bob", Amount: 1, Nonce: 1, Fee: TRANSACTION_FEE},
+	)
+	selected := ledger.SelectValid(pending, TEST_CHAIN_ID, 0)
+	if len(selected) != 2 || selected[0].Transaction.ID != "0" || selected[1].Transaction.ID != "3" {
+		t.Errorf("selected %v transactions", len(selected))
+	}
+	if err := ledger.CheckBlock(selected, TEST_CHAIN_ID, 0); err != nil {
+		t.Error("selected transactions rejected: " + err.Error())
+	}
+}
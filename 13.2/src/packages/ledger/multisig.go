@@ -0,0 +1,129 @@
+/**
+The address of a multisignature account is derived from its policy, the threshold and the
+sorted list of public keys, so it cannot collide with the address of a single public key.
+Transactions from the account carry one signature per cosigner instead of a single signature.
+**/
+
+package ledger
+
+import (
+	"encoding/json"
+	"errors"
+	"packages/address"
+	"packages/signature"
+	"sort"
+	"strconv"
+)
+
+const KIND_CREATE_MULTISIG = "createMultisig"
+
+const MAX_MULTISIG_KEYS = 16
+
+const multisigTag = "multisig:"
+
+/* Multisignature policy struct: at least Threshold of the public keys must sign */
+type MultisigPolicy struct {
+	Threshold  int
+	PublicKeys []string
+}
+
+/* Signature of a cosigner of a multisignature transaction */
+type MultisigSignature struct {
+	PublicKey string
+	Scheme    string
+	Signature string
+}
+
+/* Address of the account controlled by a policy */
+func (policy MultisigPolicy) Account() string {
+	publicKeys := append([]string{}, policy.PublicKeys...)
+	sort.Strings(publicKeys)
+	policyBytes, err := json.Marshal(MultisigPolicy{Threshold: policy.Threshold, PublicKeys: publicKeys})
+	if err != nil {
+		panic(err)
+	}
+	return address.FromPublicKey(multisigTag + string(policyBytes))
+}
+
+/* Check that a policy is well-formed */
+func (policy MultisigPolicy) Validate() error {
+	if len(policy.PublicKeys) < 1 || len(policy.PublicKeys) > MAX_MULTISIG_KEYS {
+		return errors.New("multisignature account must have between 1 and " + strconv.Itoa(MAX_MULTISIG_KEYS) + " public keys")
+	}
+	if policy.Threshold < 1 || policy.Threshold > len(policy.PublicKeys) {
+		return errors.New("multisignature threshold must be between 1 and the number of public keys")
+	}
+	seen := make(map[string]bool)
+	for _, publicKey := range policy.PublicKeys {
+		if seen[publicKey] {
+			return errors.New("multisignature account lists a public key twice")
+		}
+		seen[publicKey] = true
+		if _, err := signature.ParseVerifier(publicKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/* Check that signatures come from at least Threshold distinct keys of the policy. The signatures themselves are verified separately */
+func (policy MultisigPolicy) CheckSigners(signatures []MultisigSignature) error {
+	signers, err := policy.CountSigners(signatures)
+	if err != nil {
+		return err
+	}
+	if signers < policy.Threshold {
+		return errors.New("transaction has " + strconv.Itoa(signers) + " of the " + strconv.Itoa(policy.Threshold) + " signatures needed")
+	}
+	return nil
+}
+
+/* Count the distinct keys of the policy that signatures come from, failing for keys that are not listed or sign twice */
+func (policy MultisigPolicy) CountSigners(signatures []MultisigSignature) (int, error) {
+	listed := make(map[string]bool)
+	for _, publicKey := range policy.PublicKeys {
+		listed[publicKey] = true
+	}
+	signed := make(map[string]bool)
+	for _, cosignature := range signatures {
+		if !listed[cosignature.PublicKey] {
+			return 0, errors.New("transaction is signed by a key that does not control the account")
+		}
+		if signed[cosignature.PublicKey] {
+			return 0, errors.New("transaction is signed twice by the same key")
+		}
+		signed[cosignature.PublicKey] = true
+	}
+	return len(signed), nil
+}
+
+/* Get the policy of a multisignature account */
+func (ledger *Ledger) GetMultisigPolicy(account string) (MultisigPolicy, bool) {
+	ledger.LedgerLock.Lock()
+	defer ledger.LedgerLock.Unlock()
+	policy, found := ledger.MultisigAccounts[account]
+	return policy, found
+}
+
+func (ledger *Ledger) checkCreateMultisig(transaction Transaction) error {
+	policy := transaction.Multisig
+	if policy == nil {
+		return errors.New("transaction carries no multisignature policy")
+	}
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	if transaction.To != policy.Account() {
+		return errors.New("receiver must be the address of the multisignature account")
+	}
+	if _, found := ledger.GetMultisigPolicy(transaction.To); found {
+		return errors.New("multisignature account already exists")
+	}
+	if transaction.Amount < 0 {
+		return errors.New("amount must not be negative")
+	}
+	if transaction.Amount+transaction.Fee > ledger.GetBalance(transaction.From) {
+		return errors.New("insufficient funds in the sender's account")
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package ledger
+
+import (
+	"packages/signature"
+	"testing"
+)
+
+/* Generate Ed25519 signers, whose keys are quick to generate and valid VRF keys */
+func generateSigners(t *testing.T, count int) []signature.Signer {
+	signers := make([]signature.Signer, count)
+	for i := range signers {
+		signer, err := signature.GenerateSigner(signature.KEY_TYPE_ED25519)
+		if err != nil {
+			t.Fatal(err)
+		}
+		signers[i] = signer
+	}
+	return signers
+}
+
+func publicKeys(signers []signature.Signer) []string {
+	keys := make([]string, len(signers))
+	for i, signer := range signers {
+		keys[i] = signer.PublicKey()
+	}
+	return keys
+}
+
+func TestMultisigPolicyValidate(t *testing.T) {
+	keys := publicKeys(generateSigners(t, MAX_MULTISIG_KEYS+1))
+	if err := (MultisigPolicy{Threshold: 2, PublicKeys: keys[:3]}).Validate(); err != nil {
+		t.Error("valid policy rejected: " + err.Error())
+	}
+	for name, policy := range map[string]MultisigPolicy{
+		"no keys":             {Threshold: 1},
+		"zero threshold":      {Threshold: 0, PublicKeys: keys[:3]},
+		"threshold too large": {Threshold: 4, PublicKeys: keys[:3]},
+		"too many keys":       {Threshold: 1, PublicKeys: keys},
+		"duplicate key":       {Threshold: 2, PublicKeys: []string{keys[0], keys[1], keys[0]}},
+		"invalid key":         {Threshold: 1, PublicKeys: []string{"key"}},
+	} {
+		if policy.Validate() == nil {
+			t.Error(name + " accepted")
+		}
+	}
+}
+
+/* The address of an account depends on its keys and threshold, not on the order of the keys */
+func TestMultisigPolicyAccount(t *testing.T) {
+	keys := publicKeys(generateSigners(t, 3))
+	policy := MultisigPolicy{Threshold: 2, PublicKeys: keys}
+	reordered := MultisigPolicy{Threshold: 2, PublicKeys: []string{keys[2], keys[0], keys[1]}}
+	if policy.Account() != reordered.Account() {
+		t.Error("account depends on the order of the keys")
+	}
+	if policy.Account() == (MultisigPolicy{Threshold: 1, PublicKeys: keys}).Account() {
+		t.Error("policies with different thresholds share an account")
+	}
+}
+
+/* Signatures must come from Threshold distinct listed keys */
+func TestMultisigCheckSigners(t *testing.T) {
+	keys := publicKeys(generateSigners(t, 4))
+	policy := MultisigPolicy{Threshold: 2, PublicKeys: keys[:3]}
+	cosignature := func(key string) MultisigSignature { return MultisigSignature{PublicKey: key} }
+
+	if err := policy.CheckSigners([]MultisigSignature{cosignature(keys[0]), cosignature(keys[2])}); err != nil {
+		t.Error("threshold of distinct signers rejected: " + err.Error())
+	}
+	for name, signatures := range map[string][]MultisigSignature{
+		"one signer":       {cosignature(keys[1])},
+		"same signer":      {cosignature(keys[1]), cosignature(keys[1])},
+		"unlisted signer":  {cosignature(keys[0]), cosignature(keys[3])},
+		"no signers":       {},
+		"listed plus same": {cosignature(keys[0]), cosignature(keys[1]), cosignature(keys[1])},
+	} {
+		if policy.CheckSigners(signatures) == nil {
+			t.Error(name + " accepted")
+		}
+	}
+}
+
+func TestCreateMultisig(t *testing.T) {
+	keys := publicKeys(generateSigners(t, 3))
+	policy := MultisigPolicy{Threshold: 2, PublicKeys: keys}
+	ledger := MakeLedger()
+	ledger.Accounts["founder"] = 50
+	create := Transaction{Kind: KIND_CREATE_MULTISIG, From: "founder", To: policy.Account(), Amount: 20, Fee: TRANSACTION_FEE, Multisig: &policy}
+
+	block := signedTransactions(create)
+	if err := ledger.CheckBlock(block, TEST_CHAIN_ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	ledger.ExecuteTransaction(block[0])
+	if created, _ := ledger.GetMultisigPolicy(policy.Account()); created.Threshold != 2 || ledger.GetBalance(policy.Account()) != 20 {
+		t.Error("multisignature account not created")
+	}
+
+	// an existing account, or the account of another policy, cannot be taken over
+	other := MultisigPolicy{Threshold: 1, PublicKeys: keys[:1]}
+	for name, transaction := range map[string]Transaction{
+		"existing account": {Kind: KIND_CREATE_MULTISIG, From: "founder", To: policy.Account(), Nonce: 1, Fee: TRANSACTION_FEE, Multisig: &policy},
+		"other policy":     {Kind: KIND_CREATE_MULTISIG, From: "founder", To: policy.Account(), Nonce: 1, Fee: TRANSACTION_FEE, Multisig: &other},
+		"no policy":        {Kind: KIND_CREATE_MULTISIG, From: "founder", To: other.Account(), Nonce: 1, Fee: TRANSACTION_FEE},
+		"negative amount":  {Kind: KIND_CREATE_MULTISIG, From: "founder", To: other.Account(), Amount: -5, Nonce: 1, Fee: TRANSACTION_FEE, Multisig: &other},
+		"overdraw":         {Kind: KIND_CREATE_MULTISIG, From: "founder", To: other.Account(), Amount: 30, Nonce: 1, Fee: TRANSACTION_FEE, Multisig: &other},
+	} {
+		if err := ledger.CheckBlock(signedTransactions(transaction), TEST_CHAIN_ID, 0); err == nil {
+			t.Error(name + " accepted")
+		}
+	}
+}
+
+/* The same account created twice in a block must be rejected, as the second creation would replace the first policy */
+func TestCreateMultisigTwiceInBlock(t *testing.T) {
+	keys := publicKeys(generateSigners(t, 2))
+	policy := MultisigPolicy{Threshold: 1, PublicKeys: keys}
+	ledger := MakeLedger()
+	ledger.Accounts["founder"] = 50
+	ledger.Accounts["attacker"] = 50
+	first := Transaction{Kind: KIND_CREATE_MULTISIG, From: "founder", To: policy.Account(), Amount: 20, Fee: TRANSACTION_FEE, Multisig: &policy}
+	second := Transaction{Kind: KIND_CREATE_MULTISIG, From: "attacker", To: policy.Account(), Fee: TRANSACTION_FEE, Multisig: &policy}
+	if err := ledger.CheckBlock(signedTransactions(first, second), TEST_CHAIN_ID, 0); err == nil {
+		t.Error("multisignature account created twice in a block")
+	}
+}
@@ -0,0 +1,36 @@
+package ledger
+
+import (
+	"packages/address"
+	"sync"
+)
+
+/* Key registry struct, mapping account addresses to the public keys they were derived from */
+type KeyRegistry struct {
+	keys         map[string]string
+	registryLock sync.Mutex
+}
+
+/* Key registry constructor */
+func MakeKeyRegistry() *KeyRegistry {
+	registry := new(KeyRegistry)
+	registry.keys = make(map[string]string)
+	return registry
+}
+
+/* Register a public key and return the address of its account */
+func (registry *KeyRegistry) Register(publicKey string) string {
+	account := address.FromPublicKey(publicKey)
+	registry.registryLock.Lock()
+	registry.keys[account] = publicKey
+	registry.registryLock.Unlock()
+	return account
+}
+
+/* Look up the public key of an account */
+func (registry *KeyRegistry) Lookup(account string) (string, bool) {
+	registry.registryLock.Lock()
+	defer registry.registryLock.Unlock()
+	publicKey, found := registry.keys[account]
+	return publicKey, found
+}
@@ -0,0 +1,95 @@
+/**
+An account keeps the address derived from its first key for good. Until it is rotated that key
+signs for it; afterwards the current key is stored in the ledger. A rotate-key transaction is
+signed by the current key, or by a recovery key registered earlier in case the current key is
+lost or compromised. Recovery keys may only rotate the key, not spend.
+**/
+
+package ledger
+
+import (
+	"errors"
+	"packages/address"
+	"packages/signature"
+	"packages/vrf"
+)
+
+const KIND_ROTATE_KEY = "rotateKey"
+const KIND_REGISTER_RECOVERY_KEY = "registerRecoveryKey"
+
+/* Get the key currently authorised to sign for an account, if it has been rotated */
+func (ledger *Ledger) GetAccountKey(account string) (string, bool) {
+	ledger.LedgerLock.Lock()
+	defer ledger.LedgerLock.Unlock()
+	accountKey, found := ledger.AccountKeys[account]
+	return accountKey, found
+}
+
+/* Get the recovery key registered for an account */
+func (ledger *Ledger) GetRecoveryKey(account string) (string, bool) {
+	ledger.LedgerLock.Lock()
+	defer ledger.LedgerLock.Unlock()
+	recoveryKey, found := ledger.RecoveryKeys[account]
+	return recoveryKey, found
+}
+
+/* Check if a key is the one currently authorised to sign for an account */
+func (ledger *Ledger) IsAccountKey(account string, publicKey string) bool {
+	if accountKey, rotated := ledger.GetAccountKey(account); rotated {
+		return accountKey == publicKey
+	}
+	return address.Matches(account, publicKey)
+}
+
+/* Check that a key may sign a transaction for its sender: the current key of the account, or its recovery key for a rotation */
+func (ledger *Ledger) CheckSigningKey(transaction Transaction, publicKey string) error {
+	if ledger.IsAccountKey(transaction.From, publicKey) {
+		return nil
+	}
+	if recoveryKey, found := ledger.GetRecoveryKey(transaction.From); found && recoveryKey == publicKey && transaction.Kind == KIND_ROTATE_KEY {
+		return nil
+	}
+	return errors.New("key is not authorised to sign for account " + transaction.From)
+}
+
+func (ledger *Ledger) checkRotateKey(transaction Transaction) error {
+	if err := ledger.checkAccountKeyChange(transaction, transaction.NewKey); err != nil {
+		return err
+	}
+	if ledger.IsAccountKey(transaction.From, transaction.NewKey) {
+		return errors.New("new key is already the key of the account")
+	}
+	// the account key produces the blocks of the account
+	if err := vrf.ValidateKey(transaction.NewKey); err != nil {
+		return errors.New("new key cannot draw in the lottery: " + err.Error())
+	}
+	return nil
+}
+
+func (ledger *Ledger) checkRecoveryKey(transaction Transaction) error {
+	if err := ledger.checkAccountKeyChange(transaction, transaction.RecoveryKey); err != nil {
+		return err
+	}
+	// a recovery key equal to the account key could not recover anything
+	if ledger.IsAccountKey(transaction.From, transaction.RecoveryKey) {
+		return errors.New("recovery key must not be the key of the account")
+	}
+	return nil
+}
+
+/* Checks shared by the transactions changing the keys of an account */
+func (ledger *Ledger) checkAccountKeyChange(transaction Transaction, publicKey string) error {
+	if _, err := signature.ParseVerifier(publicKey); err != nil {
+		return errors.New("transaction does not carry a valid public key")
+	}
+	if _, found := ledger.GetMultisigPolicy(transaction.From); found {
+		return errors.New("the keys of a multisignature account cannot be changed")
+	}
+	if transaction.Amount != 0 {
+		return errors.New("key change must not send an amount")
+	}
+	if transaction.Fee > ledger.GetBalance(transaction.From) {
+		return errors.New("insufficient funds in the sender's account")
+	}
+	return nil
+}
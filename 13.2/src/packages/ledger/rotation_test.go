@@ -0,0 +1,68 @@
+package ledger
+
+import (
+	"packages/address"
+	"testing"
+)
+
+func TestRotateKey(t *testing.T) {
+	signers := generateSigners(t, 3)
+	oldKey, newKey, recoveryKey := signers[0].PublicKey(), signers[1].PublicKey(), signers[2].PublicKey()
+	account := address.FromPublicKey(oldKey)
+	ledger := MakeLedger()
+	ledger.Accounts[account] = 10
+
+	block := signedTransactions(
+		Transaction{Kind: KIND_REGISTER_RECOVERY_KEY, From: account, Nonce: 0, Fee: TRANSACTION_FEE, RecoveryKey: recoveryKey},
+		Transaction{Kind: KIND_ROTATE_KEY, From: account, Nonce: 1, Fee: TRANSACTION_FEE, NewKey: newKey},
+	)
+	if err := ledger.CheckBlock(block, TEST_CHAIN_ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	ledger.ExecuteTransaction(block[0])
+	ledger.ExecuteTransaction(block[1])
+
+	// the address stays, the key signing for it changes
+	if !ledger.IsAccountKey(account, newKey) || ledger.IsAccountKey(account, oldKey) {
+		t.Error("account key not rotated")
+	}
+	transfer := Transaction{From: account, To: "bob", Amount: 1, Nonce: 2, Fee: TRANSACTION_FEE}
+	rotate := Transaction{Kind: KIND_ROTATE_KEY, From: account, Nonce: 2, Fee: TRANSACTION_FEE, NewKey: oldKey}
+	if ledger.CheckSigningKey(transfer, newKey) != nil {
+		t.Error("new key cannot sign for the account")
+	}
+	if ledger.CheckSigningKey(transfer, oldKey) == nil {
+		t.Error("old key can still sign for the account")
+	}
+	// the recovery key may only rotate the key, not spend
+	if ledger.CheckSigningKey(rotate, recoveryKey) != nil {
+		t.Error("recovery key cannot rotate the key")
+	}
+	if ledger.CheckSigningKey(transfer, recoveryKey) == nil {
+		t.Error("recovery key can spend from the account")
+	}
+}
+
+func TestRotateKeyRejected(t *testing.T) {
+	signers := generateSigners(t, 3)
+	accountKey := signers[0].PublicKey()
+	account := address.FromPublicKey(accountKey)
+	policy := MultisigPolicy{Threshold: 1, PublicKeys: []string{signers[1].PublicKey(), signers[2].PublicKey()}}
+	ledger := MakeLedger()
+	ledger.Accounts[account] = 10
+	ledger.Accounts[policy.Account()] = 10
+	ledger.MultisigAccounts[policy.Account()] = policy
+
+	for name, transaction := range map[string]Transaction{
+		"rotation to the current key": {Kind: KIND_ROTATE_KEY, From: account, Fee: TRANSACTION_FEE, NewKey: accountKey},
+		"rotation to an invalid key":  {Kind: KIND_ROTATE_KEY, From: account, Fee: TRANSACTION_FEE, NewKey: "key"},
+		"rotation with an amount":     {Kind: KIND_ROTATE_KEY, From: account, To: "bob", Amount: 5, Fee: TRANSACTION_FEE, NewKey: signers[1].PublicKey()},
+		"rotation of a multisig":      {Kind: KIND_ROTATE_KEY, From: policy.Account(), Fee: TRANSACTION_FEE, NewKey: signers[1].PublicKey()},
+		"recovery key equal to key":   {Kind: KIND_REGISTER_RECOVERY_KEY, From: account, Fee: TRANSACTION_FEE, RecoveryKey: accountKey},
+		"recovery key of a multisig":  {Kind: KIND_REGISTER_RECOVERY_KEY, From: policy.Account(), Fee: TRANSACTION_FEE, RecoveryKey: signers[1].PublicKey()},
+	} {
+		if err := ledger.CheckBlock(signedTransactions(transaction), TEST_CHAIN_ID, 0); err == nil {
+			t.Error(name + " accepted")
+		}
+	}
+}
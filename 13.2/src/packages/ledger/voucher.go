@@ -0,0 +1,173 @@
+/**
+An issuer registers an RSA blind signing key with a fixed denomination. Anyone can then get a
+voucher signed blindly and redeem it once, for the denomination paid out of the issuer's account,
+without the issuer being able to link the redemption to the signing. The serial of a voucher is
+the public key of a one-time Ed25519 key, which must sign the redeem transaction, so whoever sees
+a pending redemption cannot copy the voucher into a transaction of their own.
+**/
+
+package ledger
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"packages/RSA"
+	"strconv"
+)
+
+const KIND_REGISTER_VOUCHER_ISSUER = "registerVoucherIssuer"
+const KIND_REDEEM_VOUCHER = "redeemVoucher"
+
+const VOUCHER_SERIAL_BYTES = ed25519.PublicKeySize
+
+/* Voucher issuer struct, registered by the account paying for its vouchers */
+type VoucherIssuer struct {
+	PublicKey    string // RSA public key used only to blindly sign vouchers
+	Denomination int    // Amount paid for each voucher
+}
+
+/* Voucher struct, carried by a redeem transaction */
+type Voucher struct {
+	Issuer    string // Account of the issuer
+	Serial    string // Hex-encoded public key of the one-time key redeeming the voucher, chosen by the holder
+	Signature string // Hex-encoded unblinded signature of the voucher message
+
+	RedeemSignature string `json:",omitempty"` // Hex-encoded signature of the redeem transaction by the one-time key
+}
+
+/* Held voucher struct, kept in a voucher file by its holder. Whoever holds it can redeem it */
+type HeldVoucher struct {
+	Voucher   Voucher
+	SerialKey string // Hex-encoded seed of the one-time key
+}
+
+/* Message signed blindly by the issuer of a voucher */
+type VoucherMessage struct {
+	Type    string // voucher
+	ChainID string
+	Issuer  string
+	Serial  string
+}
+
+/* Encoding of the message signed blindly by the issuer of a voucher */
+func VoucherMessageBytes(chainID string, issuer string, serial string) []byte {
+	message, err := json.Marshal(VoucherMessage{Type: "voucher", ChainID: chainID, Issuer: issuer, Serial: serial})
+	if err != nil {
+		panic(err)
+	}
+	return message
+}
+
+/* Encoding of a redeem transaction signed by the one-time key of its voucher, which is everything but that signature */
+func RedemptionMessageBytes(transaction Transaction) []byte {
+	if transaction.Voucher != nil {
+		voucher := *transaction.Voucher
+		voucher.RedeemSignature = ""
+		transaction.Voucher = &voucher
+	}
+	message, err := json.Marshal(transaction)
+	if err != nil {
+		panic(err)
+	}
+	return message
+}
+
+/* Sign a redeem transaction with the one-time key of the voucher, once everything else in the transaction is set */
+func (held HeldVoucher) SignRedemption(transaction *Transaction) error {
+	seed, err := hex.DecodeString(held.SerialKey)
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return errors.New("voucher file does not hold a valid one-time key")
+	}
+	voucher := held.Voucher
+	transaction.Voucher = &voucher
+	voucher.RedeemSignature = hex.EncodeToString(ed25519.Sign(ed25519.NewKeyFromSeed(seed), RedemptionMessageBytes(*transaction)))
+	return nil
+}
+
+func (ledger *Ledger) checkVoucherIssuer(transaction Transaction) error {
+	issuer := transaction.VoucherIssuer
+	if issuer == nil || issuer.Denomination < 1 {
+		return errors.New("voucher issuer must have a denomination of at least 1 AU")
+	}
+	// outstanding vouchers are signed with the registered key and promise its denomination
+	ledger.LedgerLock.Lock()
+	_, registered := ledger.VoucherIssuers[transaction.From]
+	ledger.LedgerLock.Unlock()
+	if registered {
+		return errors.New("voucher issuer is already registered and cannot be changed")
+	}
+	key, err := RSA.ParseKey(issuer.PublicKey)
+	if err != nil || key.IsPrivate() {
+		return errors.New("voucher issuer key must be an RSA public key")
+	}
+	// a blind signing key signs anything, so it must not be the key of the account
+	if ledger.IsAccountKey(transaction.From, issuer.PublicKey) {
+		return errors.New("voucher issuer key must not be the key of the issuer's account")
+	}
+	if transaction.Amount != 0 {
+		return errors.New("voucher issuer registration must not send an amount")
+	}
+	if transaction.Fee > ledger.GetBalance(transaction.From) {
+		return errors.New("insufficient funds in the sender's account")
+	}
+	return nil
+}
+
+/* Check that a redeem transaction carries a valid, unspent voucher that the issuer can pay */
+func (ledger *Ledger) CheckRedemption(transaction Transaction, chainID string) error {
+	voucher := transaction.Voucher
+	if voucher == nil {
+		return errors.New("redeem transaction carries no voucher")
+	}
+	ledger.LedgerLock.Lock()
+	issuer, registered := ledger.VoucherIssuers[voucher.Issuer]
+	spent := ledger.SpentSerials[voucherSerialKey(*voucher)]
+	issuerBalance := ledger.Accounts[voucher.Issuer]
+	ledger.LedgerLock.Unlock()
+	if !registered {
+		return errors.New("voucher issuer " + voucher.Issuer + " is not registered")
+	}
+	serial, err := hex.DecodeString(voucher.Serial)
+	if err != nil || len(serial) != VOUCHER_SERIAL_BYTES {
+		return errors.New("voucher serial must be " + strconv.Itoa(VOUCHER_SERIAL_BYTES) + " hex-encoded bytes")
+	}
+	redeemSignature, err := hex.DecodeString(voucher.RedeemSignature)
+	if err != nil || !ed25519.Verify(ed25519.PublicKey(serial), RedemptionMessageBytes(transaction), redeemSignature) {
+		return errors.New("redeem transaction is not signed by the one-time key of the voucher")
+	}
+	if spent {
+		return errors.New("voucher " + voucher.Serial + " has already been redeemed")
+	}
+	if transaction.Amount != issuer.Denomination {
+		return errors.New("redeem transaction must claim the denomination of " + strconv.Itoa(issuer.Denomination) + " AU")
+	}
+	if transaction.Fee >= transaction.Amount {
+		return errors.New("fee must be smaller than the denomination of the voucher")
+	}
+	if issuerBalance < issuer.Denomination {
+		return errors.New("insufficient funds in the issuer's account")
+	}
+	key, err := RSA.ParseKey(issuer.PublicKey)
+	if err != nil {
+		return err
+	}
+	signature, ok := new(big.Int).SetString(voucher.Signature, 16)
+	if !ok || !RSA.VerifyFDH(key, VoucherMessageBytes(chainID, voucher.Issuer, voucher.Serial), signature) {
+		return errors.New("voucher signature is invalid")
+	}
+	return nil
+}
+
+/* Check if two transactions redeem the same voucher */
+func RedeemSameVoucher(transaction1 Transaction, transaction2 Transaction) bool {
+	return transaction1.Kind == KIND_REDEEM_VOUCHER && transaction2.Kind == KIND_REDEEM_VOUCHER &&
+		transaction1.Voucher != nil && transaction2.Voucher != nil &&
+		voucherSerialKey(*transaction1.Voucher) == voucherSerialKey(*transaction2.Voucher)
+}
+
+func voucherSerialKey(voucher Voucher) string {
+	return voucher.Issuer + ":" + voucher.Serial
+}
@@ -0,0 +1,167 @@
+package ledger
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"packages/RSA"
+	"strconv"
+	"testing"
+)
+
+const TEST_CHAIN_ID = "test"
+
+/* Wrap transactions in signed transactions. Ledger checks do not verify signatures, the peer does before them */
+func signedTransactions(transactions ...Transaction) []SignedTransaction {
+	signed := make([]SignedTransaction, len(transactions))
+	for i, transaction := range transactions {
+		if transaction.ID == "" {
+			transaction.ID = strconv.Itoa(i)
+		}
+		transaction.ChainID = TEST_CHAIN_ID
+		signed[i] = SignedTransaction{Type: "signedTransaction", Transaction: transaction}
+	}
+	return signed
+}
+
+/* Ledger with a registered voucher issuer, returning the private blind signing key of the issuer */
+func voucherLedger(t *testing.T, issuerBalance int, denomination int) (*Ledger, RSA.Key) {
+	publicKey, privateKey, err := RSA.GenerateKey(1024, RSA.DEFAULT_E)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ledger := MakeLedger()
+	ledger.Accounts["issuer"] = issuerBalance + TRANSACTION_FEE
+	register := Transaction{Kind: KIND_REGISTER_VOUCHER_ISSUER, From: "issuer", Fee: TRANSACTION_FEE,
+		VoucherIssuer: &VoucherIssuer{PublicKey: publicKey.ToString(), Denomination: denomination}}
+	block := signedTransactions(register)
+	if err := ledger.CheckBlock(block, TEST_CHAIN_ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	ledger.ExecuteTransaction(block[0])
+	return ledger, privateKey
+}
+
+/* Get a voucher blindly signed by the issuer, as its holder does */
+func issueVoucher(t *testing.T, privateKey RSA.Key) HeldVoucher {
+	serial, serialKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := VoucherMessageBytes(TEST_CHAIN_ID, "issuer", hex.EncodeToString(serial))
+	blinded, r, err := RSA.Blind(privateKey.PublicKey(), message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blindSignature, err := RSA.SignBlinded(blinded, privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err := RSA.Unblind(privateKey.PublicKey(), message, blindSignature, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	voucher := Voucher{Issuer: "issuer", Serial: hex.EncodeToString(serial), Signature: signature.Text(16)}
+	return HeldVoucher{Voucher: voucher, SerialKey: hex.EncodeToString(serialKey.Seed())}
+}
+
+/* Redeem transaction of a held voucher, signed by its one-time key */
+func redemption(t *testing.T, held HeldVoucher, to string, nonce int) Transaction {
+	transaction := Transaction{ID: "redeem" + held.Voucher.Serial[:8], ChainID: TEST_CHAIN_ID, Kind: KIND_REDEEM_VOUCHER,
+		From: to, To: to, Amount: 10, Nonce: nonce, Fee: TRANSACTION_FEE}
+	if err := held.SignRedemption(&transaction); err != nil {
+		t.Fatal(err)
+	}
+	return transaction
+}
+
+func TestRedeemVoucher(t *testing.T) {
+	ledger, privateKey := voucherLedger(t, 100, 10)
+	block := signedTransactions(redemption(t, issueVoucher(t, privateKey), "holder", 0))
+	if err := ledger.CheckBlock(block, TEST_CHAIN_ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	ledger.ExecuteTransaction(block[0])
+	if ledger.GetBalance("issuer") != 90 || ledger.GetBalance("holder") != 10-TRANSACTION_FEE {
+		t.Error("denomination not paid from the issuer to the holder")
+	}
+	// the same voucher in a later block
+	again := block[0]
+	again.Transaction.Nonce = 1
+	if err := ledger.CheckBlock([]SignedTransaction{again}, TEST_CHAIN_ID, 0); err == nil {
+		t.Error("redeemed voucher accepted again")
+	}
+}
+
+/* A pending redemption copied into another transaction must not verify, since the one-time key signed the receiver */
+func TestRedeemVoucherTheft(t *testing.T) {
+	ledger, privateKey := voucherLedger(t, 100, 10)
+	stolen := redemption(t, issueVoucher(t, privateKey), "holder", 0)
+	stolen.From = "thief"
+	stolen.To = "thief"
+	if err := ledger.CheckBlock(signedTransactions(stolen), TEST_CHAIN_ID, 0); err == nil {
+		t.Error("voucher redeemed to another account than the one signed by its one-time key")
+	}
+}
+
+func TestRedeemVoucherInvalid(t *testing.T) {
+	ledger, privateKey := voucherLedger(t, 100, 10)
+	held := issueVoucher(t, privateKey)
+
+	forged := held
+	forged.Voucher.Signature = "1234"
+	wrongAmount := redemption(t, held, "holder", 0)
+	wrongAmount.Amount = 20
+	changedSerial := held
+	changedSerial.Voucher.Serial = hex.EncodeToString(make([]byte, VOUCHER_SERIAL_BYTES))
+
+	for name, transaction := range map[string]Transaction{
+		"forged signature":    redemption(t, forged, "holder", 0),
+		"changed amount":      wrongAmount,
+		"changed serial":      redemption(t, changedSerial, "holder", 0),
+		"no voucher":          {Kind: KIND_REDEEM_VOUCHER, From: "holder", To: "holder", Amount: 10, Fee: TRANSACTION_FEE},
+		"unregistered issuer": redemption(t, HeldVoucher{Voucher: Voucher{Issuer: "other", Serial: held.Voucher.Serial, Signature: held.Voucher.Signature}, SerialKey: held.SerialKey}, "holder", 0),
+	} {
+		if err := ledger.CheckBlock(signedTransactions(transaction), TEST_CHAIN_ID, 0); err == nil {
+			t.Error(name + " accepted")
+		}
+	}
+}
+
+/* Redemptions in one block must not pay out more than the issuer holds, nor the same voucher twice */
+func TestRedeemVoucherBlock(t *testing.T) {
+	ledger, privateKey := voucherLedger(t, 15, 10)
+	first := redemption(t, issueVoucher(t, privateKey), "holder", 0)
+	second := redemption(t, issueVoucher(t, privateKey), "holder", 1)
+	if err := ledger.CheckBlock(signedTransactions(first, second), TEST_CHAIN_ID, 0); err == nil {
+		t.Error("issuer overdrawn by the redemptions of a block")
+	}
+	held := issueVoucher(t, privateKey)
+	if err := ledger.CheckBlock(signedTransactions(redemption(t, held, "holder", 0), redemption(t, held, "other", 0)), TEST_CHAIN_ID, 0); err == nil {
+		t.Error("voucher redeemed twice in a block")
+	}
+}
+
+func TestRegisterVoucherIssuer(t *testing.T) {
+	ledger, _ := voucherLedger(t, 100, 10)
+	publicKey, _, err := RSA.GenerateKey(1024, RSA.DEFAULT_E)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reregister := Transaction{Kind: KIND_REGISTER_VOUCHER_ISSUER, From: "issuer", Nonce: 1, Fee: TRANSACTION_FEE,
+		VoucherIssuer: &VoucherIssuer{PublicKey: publicKey.ToString(), Denomination: 1}}
+	if err := ledger.CheckBlock(signedTransactions(reregister), TEST_CHAIN_ID, 0); err == nil {
+		t.Error("voucher issuer registered again")
+	}
+	ledger.Accounts["new"] = 10
+	for name, issuer := range map[string]*VoucherIssuer{
+		"no issuer":         nil,
+		"zero denomination": {PublicKey: publicKey.ToString()},
+		"invalid key":       {PublicKey: "key", Denomination: 1},
+	} {
+		transaction := Transaction{Kind: KIND_REGISTER_VOUCHER_ISSUER, From: "new", Fee: TRANSACTION_FEE, VoucherIssuer: issuer}
+		if err := ledger.CheckBlock(signedTransactions(transaction), TEST_CHAIN_ID, 0); err == nil {
+			t.Error(name + " accepted")
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package peer
+
+import (
+	"fmt"
+	"io/ioutil"
+	"packages/ledger"
+	"strconv"
+)
+
+/* Read a consensus key from the user and authorise it for the peer's account */
+func (peer *Peer) writeConsensusKey() {
+	publicKey, err := peer.readPublicKey("Consensus key")
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	var fee string
+	fmt.Println("Fee: ")
+	fmt.Scanln(&fee)
+	transaction := ledger.Transaction{Kind: ledger.KIND_REGISTER_CONSENSUS_KEY, To: peer.account, ConsensusKey: publicKey}
+	transaction.Fee, _ = strconv.Atoi(fee)
+	fmt.Println("Authorising consensus key for account " + peer.account)
+	peer.signAndBroadcast(transaction)
+}
+
+/* Read a public key from the user, given by the address of a peer on the network, an account address or a public key file */
+func (peer *Peer) readPublicKey(name string) (string, error) {
+	var peerAccountOrFile string
+	fmt.Println(name + ", given by the address of a peer on the network, an account address or a public key file: ")
+	fmt.Scanln(&peerAccountOrFile)
+	publicKey, err := peer.getPublicKey(peerAccountOrFile)
+	if err != nil {
+		publicKeyBytes, fileErr := ioutil.ReadFile(peerAccountOrFile)
+		if fileErr != nil {
+			return "", err
+		}
+		publicKey = string(publicKeyBytes)
+	}
+	return publicKey, nil
+}
@@ -0,0 +1,110 @@
+package peer
+
+import (
+	"encoding/json"
+	"fmt"
+	"packages/address"
+	"packages/encryption"
+	"packages/ledger"
+)
+
+/* Message struct carrying a payload encrypted to the public key of an account */
+type EncryptedMsg struct {
+	Type     string
+	From     string               // Address of the sender's account
+	Envelope *encryption.Envelope // Encrypted payload
+}
+
+/* Handle encrypted message method */
+func (peer *Peer) handleEncryptedMessage(encryptedMessage EncryptedMsg) {
+	if encryptedMessage.Envelope == nil {
+		return
+	}
+	peer.lock.Lock()
+	seen := peer.markMessageSeen(encryptedMessage.Envelope.Ciphertext)
+	peer.lock.Unlock()
+	if seen {
+		return
+	}
+
+	// if the message is addressed to this peer, decrypt it
+	if encryptedMessage.Envelope.Recipient == peer.account {
+		plaintext, err := encryption.Decrypt(encryptedMessage.Envelope, peer.account, peer.signer)
+		if err != nil {
+			fmt.Println("Could not decrypt message from " + encryptedMessage.From + ": " + err.Error())
+			return
+		}
+		fmt.Println("Message from " + encryptedMessage.From + ": " + string(plaintext))
+		return
+	}
+
+	// otherwise forward it, only the recipient can read it
+	jsonString, _ := json.Marshal(encryptedMessage)
+	peer.broadcast <- jsonString
+}
+
+/* Read an encrypted message from the user and send it */
+func (peer *Peer) writeEncryptedMessage() {
+	var receiverAddress string
+	fmt.Println("Receiver's address: ")
+	fmt.Scanln(&receiverAddress)
+	fmt.Println("Message: ")
+	message := ReadLine()
+
+	envelope, err := peer.encryptTo(receiverAddress, message)
+	if err != nil {
+		fmt.Println("Could not encrypt message: " + err.Error())
+		return
+	}
+	encryptedMessage := &EncryptedMsg{Type: "encryptedMessage", From: peer.account, Envelope: envelope}
+	peer.lock.Lock()
+	peer.markMessageSeen(envelope.Ciphertext)
+	peer.lock.Unlock()
+	jsonString, _ := json.Marshal(encryptedMessage)
+	peer.broadcast <- jsonString
+}
+
+/* Encrypt a text to the account of a receiver, given by the address of a peer on the network or an account address */
+func (peer *Peer) encryptTo(receiverAddress string, text string) (*encryption.Envelope, error) {
+	receiverAccount, err := peer.getReceiverAccount(receiverAddress)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := peer.getPublicKey(receiverAddress)
+	if err != nil {
+		return nil, err
+	}
+	return encryption.Encrypt([]byte(text), receiverAccount, publicKey)
+}
+
+/* Print the memo of a transaction received by the peer */
+func (peer *Peer) printMemo(transaction ledger.Transaction) {
+	if transaction.Memo == nil || transaction.To != peer.account {
+		return
+	}
+	memo, err := encryption.Decrypt(transaction.Memo, peer.account, peer.signer)
+	if err != nil {
+		fmt.Println("Could not decrypt memo of transaction " + transaction.ID + ": " + err.Error())
+		return
+	}
+	fmt.Println("Memo of transaction " + transaction.ID + " from " + transaction.From + ": " + string(memo))
+}
+
+/* Remember an encrypted message as seen, forgetting the oldest one beyond MAX_MESSAGES_SEEN. Returns whether it was seen before. The lock must be held */
+func (peer *Peer) markMessageSeen(ciphertext string) bool {
+	if peer.messagesSeen[ciphertext] {
+		return true
+	}
+	peer.messagesSeen[ciphertext] = true
+	peer.messagesSeenOrder = append(peer.messagesSeenOrder, ciphertext)
+	if len(peer.messagesSeenOrder) > MAX_MESSAGES_SEEN {
+		delete(peer.messagesSeen, peer.messagesSeenOrder[0])
+		peer.messagesSeenOrder = peer.messagesSeenOrder[1:]
+	}
+	return false
+}
+
+/* Check that the memo of a transaction is addressed to the receiver of the transaction */
+func validMemo(transaction ledger.Transaction) bool {
+	return transaction.Memo == nil || address.Validate(transaction.Memo.Recipient) == nil && transaction.Memo.Recipient == transaction.To
+}
@@ -0,0 +1,84 @@
+package peer
+
+import (
+	"fmt"
+	"packages/address"
+	"packages/ledger"
+	"strconv"
+	"strings"
+)
+
+/* Read guardians and a threshold from the user and nominate them for the peer's account */
+func (peer *Peer) writeGuardians() {
+	var guardians, threshold, fee string
+	fmt.Println("Guardians, given by comma-separated addresses of peers on the network or account addresses: ")
+	fmt.Scanln(&guardians)
+	fmt.Println("Number of guardians needed to recover the account: ")
+	fmt.Scanln(&threshold)
+	fmt.Println("Fee: ")
+	fmt.Scanln(&fee)
+
+	policy := &ledger.GuardianPolicy{}
+	for _, guardian := range strings.Split(guardians, ",") {
+		guardianAccount, err := peer.getReceiverAccount(guardian)
+		if err != nil {
+			fmt.Println(guardian + " is not a peer or a valid address: " + err.Error())
+			return
+		}
+		policy.Guardians = append(policy.Guardians, guardianAccount)
+	}
+	policy.Threshold, _ = strconv.Atoi(threshold)
+	if err := policy.Validate(peer.account); err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	transaction := ledger.Transaction{Kind: ledger.KIND_SET_GUARDIANS, To: peer.account, Guardians: policy}
+	transaction.Fee, _ = strconv.Atoi(fee)
+	peer.signAndBroadcast(transaction)
+}
+
+/* Approve, as a guardian, the recovery of an account to a new key */
+func (peer *Peer) writeRecoveryApproval() {
+	var account, fee string
+	fmt.Println("Account to recover: ")
+	fmt.Scanln(&account)
+	if err := address.Validate(account); err != nil {
+		fmt.Println(account + " is not a valid address: " + err.Error())
+		return
+	}
+	if policy, found := peer.ledger.GetGuardians(account); !found || !policy.IsGuardian(peer.account) {
+		fmt.Println("The peer's account is not a guardian of account " + account)
+		return
+	}
+	newKey, err := peer.readPublicKey("New account key")
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	fmt.Println("Fee: ")
+	fmt.Scanln(&fee)
+	transaction := ledger.Transaction{Kind: ledger.KIND_APPROVE_RECOVERY, To: account, NewKey: newKey}
+	transaction.Fee, _ = strconv.Atoi(fee)
+	fmt.Println("Approving recovery of account " + account + " to key " + address.FromPublicKey(newKey))
+	peer.signAndBroadcast(transaction)
+}
+
+/* Cancel a recovery of the peer's account that the peer's key did not ask for */
+func (peer *Peer) writeCancelRecovery() {
+	var fee string
+	fmt.Println("Fee: ")
+	fmt.Scanln(&fee)
+	transaction := ledger.Transaction{Kind: ledger.KIND_CANCEL_RECOVERY, To: peer.account}
+	transaction.Fee, _ = strconv.Atoi(fee)
+	peer.signAndBroadcast(transaction)
+}
+
+/* Warn the user if the key of the peer's account is about to be replaced by its guardians */
+func (peer *Peer) warnPendingRecovery() {
+	recovery, pending := peer.ledger.GetPendingRecovery(peer.account)
+	if !pending || recovery.NewKey == peer.publicKey {
+		return
+	}
+	fmt.Println("WARNING: the guardians of account " + peer.account + " approved replacing its key with " + address.FromPublicKey(recovery.NewKey) +
+		" from slot " + strconv.Itoa(recovery.ReadySlot) + ". Enter 'cancel' before then if this was not asked for.")
+}
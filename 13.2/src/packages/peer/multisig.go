@@ -0,0 +1,139 @@
+package peer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"packages/ledger"
+	"strconv"
+	"strings"
+)
+
+/* Read a multisignature policy from the user and create its account, funded by the peer's account */
+func (peer *Peer) writeCreateMultisig() {
+	var threshold, amount, fee string
+	fmt.Println("Cosigners, given by addresses of peers on the network or account addresses, separated by spaces: ")
+	cosigners := strings.Fields(ReadLine())
+	fmt.Println("Number of signatures needed: ")
+	fmt.Scanln(&threshold)
+	fmt.Println("Amount to fund the account with: ")
+	fmt.Scanln(&amount)
+	fmt.Println("Fee: ")
+	fmt.Scanln(&fee)
+
+	policy := ledger.MultisigPolicy{}
+	policy.Threshold, _ = strconv.Atoi(threshold)
+	for _, cosigner := range cosigners {
+		publicKey, err := peer.getPublicKey(cosigner)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		policy.PublicKeys = append(policy.PublicKeys, publicKey)
+	}
+	if err := policy.Validate(); err != nil {
+		fmt.Println("Invalid multisignature account: " + err.Error())
+		return
+	}
+	transaction := ledger.Transaction{Kind: ledger.KIND_CREATE_MULTISIG, To: policy.Account(), Multisig: &policy}
+	transaction.Amount, _ = strconv.Atoi(amount)
+	transaction.Fee, _ = strconv.Atoi(fee)
+	fmt.Println("Creating multisignature account " + policy.Account())
+	peer.signAndBroadcast(transaction)
+}
+
+/* Read a transaction from a multisignature account from the user, sign it and write it to a file for the other cosigners */
+func (peer *Peer) writeMultisigProposal() {
+	var account, amount, receiverAddress, fee, nonce, filename string
+	fmt.Println("Multisignature account: ")
+	fmt.Scanln(&account)
+	fmt.Println("Amount to send: ")
+	fmt.Scanln(&amount)
+	fmt.Println("Receiver's address: ")
+	fmt.Scanln(&receiverAddress)
+	fmt.Println("Fee: ")
+	fmt.Scanln(&fee)
+	fmt.Println("Nonce (leave empty for the next nonce of the account): ")
+	fmt.Scanln(&nonce)
+	fmt.Println("File to write the transaction to: ")
+	fmt.Scanln(&filename)
+
+	receiverAccount, err := peer.getReceiverAccount(receiverAddress)
+	if err != nil {
+		fmt.Println("Receiver's address is invalid: " + err.Error())
+		return
+	}
+	signedTransaction := &ledger.SignedTransaction{Type: "signedTransaction"}
+	signedTransaction.Transaction.ID = account + strconv.Itoa(rand.Intn(1000000))
+	signedTransaction.Transaction.ChainID = peer.blockchain.ChainID
+	signedTransaction.Transaction.From = account
+	signedTransaction.Transaction.To = receiverAccount
+	signedTransaction.Transaction.Amount, _ = strconv.Atoi(amount)
+	signedTransaction.Transaction.Fee, _ = strconv.Atoi(fee)
+	if signedTransaction.Transaction.Nonce, err = strconv.Atoi(nonce); err != nil {
+		signedTransaction.Transaction.Nonce = peer.ledger.GetNonce(account)
+	}
+	peer.cosign(signedTransaction, filename)
+}
+
+/* Read a transaction from a multisignature account from a file and add the peer's signature to it */
+func (peer *Peer) writeCosignature() {
+	var filename string
+	fmt.Println("Transaction file: ")
+	fmt.Scanln(&filename)
+	envelope, err := ledger.ReadEnvelope(filename)
+	if err != nil {
+		fmt.Println("Could not read transaction: " + err.Error())
+		return
+	}
+	fmt.Println("Signing " + envelope.Transaction.Transaction.Describe())
+	peer.cosign(&envelope.Transaction, filename)
+}
+
+/* Add the peer's signature to a multisignature transaction, and broadcast it once it has enough signatures */
+func (peer *Peer) cosign(signedTransaction *ledger.SignedTransaction, filename string) {
+	policy, found := peer.ledger.GetMultisigPolicy(signedTransaction.Transaction.From)
+	if !found {
+		fmt.Println(signedTransaction.Transaction.From + " is not a multisignature account.")
+		return
+	}
+	scheme, cosignature, err := peer.signer.Sign(signedTransaction.Transaction)
+	if err != nil {
+		fmt.Println("Could not sign transaction: " + err.Error())
+		return
+	}
+	signatures := append(signedTransaction.Signatures, ledger.MultisigSignature{PublicKey: peer.publicKey, Scheme: scheme, Signature: cosignature})
+	signers, err := policy.CountSigners(signatures)
+	if err != nil {
+		fmt.Println("Could not sign transaction: " + err.Error())
+		return
+	}
+	signedTransaction.Signatures = signatures
+
+	if err := ledger.WriteEnvelope(filename, ledger.MakeEnvelope(*signedTransaction)); err != nil {
+		fmt.Println("Could not write transaction: " + err.Error())
+		return
+	}
+	if signers < policy.Threshold {
+		fmt.Println("Signed transaction written to " + filename + ". " + strconv.Itoa(signers) + " of " + strconv.Itoa(policy.Threshold) + " signatures collected.")
+		return
+	}
+	fmt.Println("Transaction " + signedTransaction.Transaction.ID + " has enough signatures and is broadcast.")
+	jsonString, _ := json.Marshal(signedTransaction)
+	peer.broadcast <- jsonString
+}
+
+/* Get the public key of a peer on the network or of a known account */
+func (peer *Peer) getPublicKey(peerOrAccount string) (string, error) {
+	if publicKey, found := peer.peers.PeersMap[peerOrAccount]; found {
+		return publicKey, nil
+	}
+	if publicKey, rotated := peer.ledger.GetAccountKey(peerOrAccount); rotated {
+		return publicKey, nil
+	}
+	if publicKey, found := peer.keyRegistry.Lookup(peerOrAccount); found {
+		return publicKey, nil
+	}
+	return "", errors.New("public key of " + peerOrAccount + " is unknown")
+}
@@ -9,21 +9,32 @@ package peer
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"log"
 	"math/rand"
 	"net"
-	"packages/RSA"
+	"os"
+	"packages/address"
 	"packages/blockchain"
+	"packages/encryption"
+	"packages/keystore"
 	"packages/ledger"
+	"packages/remotesigner"
+	"packages/signature"
+	"path/filepath"
+	"runtime"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
 
 const MAX_CON = 10
-const e = 3
+const NODE_KEY_FILE = "node.key"
+const MAX_MESSAGES_SEEN = 10000 // encrypted messages remembered to stop forwarding loops
 
 /* Message struct containing list of peers */
 type PeersMapMsg struct {
@@ -31,6 +42,12 @@ type PeersMapMsg struct {
 	PeersMap map[string]string // address -> public key map
 }
 
+/* Message struct sent first on every connection, identifying the network of the peer */
+type HelloMsg struct {
+	Type    string
+	ChainID string
+}
+
 /* Message struct containing address of new peer */
 type NewPeerMsg struct {
 	Type      string
@@ -52,13 +69,21 @@ type Peer struct {
 	ledger           *ledger.Ledger
 	lock             sync.Mutex
 	peers            PeersMapMsg
-	privateKey       string
+	signer           signature.Signer // holds the private key of the peer
 	publicKey        string
+	account          string              // address of the peer's account, derived from the public key
+	validatorAccount string              // account whose stake the peer plays the lottery with, using its key as consensus key
+	keyRegistry      *ledger.KeyRegistry // account address -> public key of known accounts
+	verifier         *signature.BatchVerifier
 
 	blockchain           *blockchain.Blockchain
 	pendingTransactions  map[string]ledger.SignedTransaction
 	transactionsExecuted map[string]bool
 	blocksSeen           map[string]bool
+	minFeeIncrement      int // minimum fee increase for a transaction to replace a pending one
+	nextNonce            int // nonce of the next transaction written by the peer
+	messagesSeen         map[string]bool
+	messagesSeenOrder    []string // ciphertexts in messagesSeen, oldest first
 }
 
 /* Initialize peer method */
@@ -68,6 +93,11 @@ func (peer *Peer) StartPeer() {
 	fmt.Scanln(&peer.outIP)
 	fmt.Println("Please enter port to connect to:")
 	fmt.Scanln(&peer.outPort)
+	var genesisFile string
+	fmt.Println("Please enter genesis file (leave empty for the " + blockchain.DEFAULT_NETWORK + " network):")
+	fmt.Scanln(&genesisFile)
+	peer.blockchain = peer.loadBlockchain(genesisFile)
+	peer.minFeeIncrement = readMinFeeIncrement()
 
 	/* Initialize variables */
 	ln, _ := net.Listen("tcp", "127.0.0.1:")
@@ -84,10 +114,21 @@ func (peer *Peer) StartPeer() {
 	peer.peers.Type = "peersMap"
 	peer.peers.PeersMap = make(map[string]string)
 
-	k := RSA.GenerateRandomK()
-	publicKey, privateKey := RSA.KeyGen(k, e)
-	peer.privateKey = privateKey.ToString()
-	peer.publicKey = publicKey.ToString()
+	peer.signer = peer.loadSigner()
+	peer.publicKey = peer.signer.PublicKey()
+	peer.keyRegistry = ledger.MakeKeyRegistry()
+	keyAccount := peer.keyRegistry.Register(peer.publicKey)
+	fmt.Println("Please enter the account the key was rotated to (leave empty for the key's own account):")
+	fmt.Scanln(&peer.account)
+	if peer.account == "" {
+		peer.account = keyAccount
+	}
+	fmt.Println("Please enter the account to validate for with this key as consensus key (leave empty for the peer's own account):")
+	fmt.Scanln(&peer.validatorAccount)
+	if peer.validatorAccount == "" {
+		peer.validatorAccount = peer.account
+	}
+	peer.verifier = signature.MakeCachedBatchVerifier(runtime.NumCPU(), signature.MakeVerificationCache(signature.VERIFICATION_CACHE_SIZE))
 
 	/* Print address for connectivity */
 	peer.printDetails()
@@ -98,11 +139,11 @@ func (peer *Peer) StartPeer() {
 	go peer.broadcastMsg()
 	go peer.acceptConnect()
 
-	peer.blockchain = blockchain.MakeBlockchain()
-	peer.ledger.Accounts[peer.publicKey] = 1000000
+	peer.ledger.Accounts[keyAccount] = 1000000
 	peer.pendingTransactions = make(map[string]ledger.SignedTransaction, 0)
 	peer.transactionsExecuted = make(map[string]bool)
 	peer.blocksSeen = make(map[string]bool)
+	peer.messagesSeen = make(map[string]bool)
 	go peer.playLottery()
 }
 
@@ -121,11 +162,11 @@ func (peer *Peer) connect(address string) {
 		defer peer.connect(peer.inIP + ":" + peer.inPort)
 		return
 	}
-	/* Store the connection for broadcasting */
-	peer.connections[address] = conn
+	/* Introduce the network of the peer */
+	peer.sendHello(conn)
 
-	/* Initialize reading routine associated with the conenction */
-	go peer.read(conn)
+	/* Initialize reading routine associated with the conenction, which stores it for broadcasting once its network is checked */
+	go peer.read(conn, address)
 }
 
 /* Accept connect method */
@@ -133,24 +174,26 @@ func (peer *Peer) acceptConnect() {
 	for {
 		/* Accept connection that dials */
 		conn, _ := peer.ln.Accept()
-		peer.connections[conn.RemoteAddr().String()] = conn
 		fmt.Println(peer.address + " got a connection from " + conn.RemoteAddr().String())
 		defer peer.ln.Close()
 
+		/* Introduce the network of the peer */
+		peer.sendHello(conn)
+
 		/* Forward local list of peers */
 		jsonString, _ := json.Marshal(peer.peers)
 		conn.Write(jsonString)
 
 		/* Start reading input from the connection */
-		go peer.read(conn)
+		go peer.read(conn, conn.RemoteAddr().String())
 	}
 }
 
 /* Accept disconnect */
 func (peer *Peer) acceptDisconnect(conn net.Conn) {
 	/* Locate address and remove it */
-	for address, conn := range peer.connections {
-		if conn == conn {
+	for address, connection := range peer.connections {
+		if connection == conn {
 			delete(peer.connections, address)
 			return
 		}
@@ -159,17 +202,21 @@ func (peer *Peer) acceptDisconnect(conn net.Conn) {
 	return
 }
 
-/* Read method of server */
-func (peer *Peer) read(conn net.Conn) {
+/* Read method of server. The connection receives broadcasts only after its hello shows that it is on the same network */
+func (peer *Peer) read(conn net.Conn, address string) {
 	defer conn.Close()
 	/* Decode every message into a string-interface map */
 	var temp map[string]interface{}
 	decoder := json.NewDecoder(conn)
+	helloReceived := false
 	for {
+		temp = nil
 		err := decoder.Decode(&temp)
 		/* In case of empty string, disconnect the peer */
 		if err == io.EOF {
-			peer.acceptDisconnect(conn)
+			if helloReceived {
+				peer.acceptDisconnect(conn)
+			}
 			return
 		}
 		/* In case of an error, crash the peer */
@@ -177,6 +224,16 @@ func (peer *Peer) read(conn net.Conn) {
 			log.Println(err.Error())
 			return
 		}
+		/* The first message must show that the other peer is on the same network */
+		if !helloReceived {
+			if !peer.checkHello(temp) {
+				fmt.Println("Dropping connection from " + conn.RemoteAddr().String() + " on another network.")
+				return
+			}
+			helloReceived = true
+			peer.connections[address] = conn
+			continue
+		}
 		/* Forward the map to the handleRead method */
 		peer.handleRead(temp)
 	}
@@ -206,12 +263,203 @@ func (peer *Peer) handleRead(temp map[string]interface{}) {
 		signedBlock := &blockchain.SignedBlock{}
 		json.Unmarshal(jsonString, &signedBlock)
 		peer.handleSignedBlock(*signedBlock)
+	case "encryptedMessage":
+		encryptedMessage := &EncryptedMsg{}
+		json.Unmarshal(jsonString, &encryptedMessage)
+		peer.handleEncryptedMessage(*encryptedMessage)
 	default:
 		fmt.Println("Error... Type conversion could not be performed...")
 		return
 	}
 }
 
+/* Load the signing key of the peer from the keystore or the data directory, creating it on first start */
+func (peer *Peer) loadSigner() signature.Signer {
+	var socketPath, dataDir, keystoreDir string
+	fmt.Println("Please enter signer socket (leave empty to hold the key in the peer):")
+	fmt.Scanln(&socketPath)
+	if socketPath != "" {
+		// the key stays in the signer process, which only proves draws and signs blocks; transactions of the
+		// account are signed with its account key, e.g. with the tx command
+		client, err := remotesigner.Dial(socketPath)
+		if err != nil {
+			log.Fatal("Could not connect to signer: " + err.Error())
+		}
+		fmt.Println("Using " + client.KeyType() + " key held by the signer on " + socketPath)
+		return client
+	}
+	fmt.Println("Please enter data directory (leave empty for a new temporary identity):")
+	fmt.Scanln(&dataDir)
+	fmt.Println("Please enter keystore directory (leave empty to keep the key in the data directory):")
+	fmt.Scanln(&keystoreDir)
+
+	var signer signature.Signer
+	var err error
+	if keystoreDir != "" {
+		// read whole lines, so that a password with spaces is neither cut nor left for the next prompt
+		fmt.Println("Please enter key name:")
+		name := ReadLine()
+		fmt.Println("Please enter password:")
+		password := ReadLine()
+		signer, err = LoadOrCreateKeystoreSigner(keystoreDir, name, password, readPasswordConfirmation, readKeyType)
+	} else if dataDir != "" {
+		signer, err = LoadOrCreateNodeKey(dataDir, readKeyType)
+	} else {
+		// without either, the peer gets a new temporary identity
+		signer, err = signature.GenerateSigner(readKeyType())
+	}
+	if err != nil {
+		log.Fatal("Could not load key: " + err.Error())
+	}
+
+	fingerprint, err := signature.Fingerprint(signer.PublicKey())
+	if err != nil {
+		log.Fatal("Invalid key: " + err.Error())
+	}
+	fmt.Println("Using " + signer.KeyType() + " key " + fingerprint)
+	return signer
+}
+
+/* Read from the user how much more a transaction must pay to replace a pending one with the same nonce */
+func readMinFeeIncrement() int {
+	var increment string
+	fmt.Println("Please enter the minimum fee increase to replace a pending transaction (leave empty for " + strconv.Itoa(ledger.MIN_FEE_INCREMENT) + " AU):")
+	fmt.Scanln(&increment)
+	if increment == "" {
+		return ledger.MIN_FEE_INCREMENT
+	}
+	// an increase of 0 would let a sender replace its transaction over and over for free
+	minFeeIncrement, err := strconv.Atoi(increment)
+	if err != nil || minFeeIncrement < 1 {
+		fmt.Println("Invalid fee increase " + increment + ", using " + strconv.Itoa(ledger.MIN_FEE_INCREMENT) + " AU.")
+		return ledger.MIN_FEE_INCREMENT
+	}
+	return minFeeIncrement
+}
+
+/* Read the password of a new key a second time from the user */
+func readPasswordConfirmation() string {
+	fmt.Println("Please repeat password:")
+	return ReadLine()
+}
+
+/* Read a line from the user, without the line break. Reads byte by byte, so that no input is buffered away from fmt.Scanln */
+func ReadLine() string {
+	var line strings.Builder
+	b := make([]byte, 1)
+	for {
+		n, err := os.Stdin.Read(b)
+		if err != nil || (n == 1 && b[0] == '\n') {
+			break
+		}
+		if n == 1 {
+			line.WriteByte(b[0])
+		}
+	}
+	return strings.TrimRight(line.String(), "\r")
+}
+
+/* Read the type of a new key from the user */
+func readKeyType() string {
+	var keyType string
+	fmt.Println("Please enter key type (" + signature.KEY_TYPE_RSA + " or " + signature.KEY_TYPE_ED25519 + ", leave empty for " + signature.DEFAULT_KEY_TYPE + "):")
+	fmt.Scanln(&keyType)
+	return keyType
+}
+
+/* Load the node key from the data directory, generating and saving it if it does not exist yet */
+func LoadOrCreateNodeKey(dataDir string, keyType func() string) (signature.Signer, error) {
+	keyFile := filepath.Join(dataDir, NODE_KEY_FILE)
+	keyBytes, err := ioutil.ReadFile(keyFile)
+	if err == nil {
+		return signature.ParsePrivateKey(string(keyBytes))
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	fmt.Println("No node key found in " + dataDir + ", creating a new one.")
+	signer, err := signature.GenerateSigner(keyType())
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := signature.EncodePrivateKeyPEM(signer)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dataDir, 0700); err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(keyFile, []byte(encoded), 0600); err != nil {
+		return nil, err
+	}
+	return signer, nil
+}
+
+/* Load a signer from a keystore, generating and storing the key if it does not exist yet. The password of a new key must be confirmed */
+func LoadOrCreateKeystoreSigner(keystoreDir string, name string, password string, confirmPassword func() string, keyType func() string) (signature.Signer, error) {
+	store, err := keystore.Open(keystoreDir)
+	if err != nil {
+		return nil, err
+	}
+	if !store.Has(name) {
+		fmt.Println("No key " + name + " found in " + keystoreDir + ", creating a new one.")
+		if confirmPassword() != password {
+			return nil, errors.New("passwords do not match")
+		}
+		signer, err := signature.GenerateSigner(keyType())
+		if err != nil {
+			return nil, err
+		}
+		return signer, store.Store(name, password, signer.PrivateKey(), signer.PublicKey())
+	}
+	return LoadSignerFromKeystore(keystoreDir, name, password)
+}
+
+/* Load a signer from the key stored under a name in a keystore */
+func LoadSignerFromKeystore(keystoreDir string, name string, password string) (signature.Signer, error) {
+	store, err := keystore.Open(keystoreDir)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, publicKey, err := store.Load(name, password)
+	if err != nil {
+		return nil, err
+	}
+	return signature.MakeSigner(privateKey, publicKey)
+}
+
+/* Load the blockchain of the network described by a genesis file, or of the default network */
+func (peer *Peer) loadBlockchain(genesisFile string) *blockchain.Blockchain {
+	genesis := blockchain.DefaultGenesis(blockchain.DEFAULT_NETWORK)
+	if genesisFile != "" {
+		var err error
+		genesis, err = blockchain.LoadGenesis(genesisFile)
+		if err != nil {
+			log.Fatal("Could not read genesis file: " + err.Error())
+		}
+	}
+	chain := blockchain.MakeBlockchainFromGenesis(genesis)
+	fmt.Println("Joining network " + genesis.Network + " with chain ID " + chain.ChainID)
+	return chain
+}
+
+/* Send the chain ID of the peer on a new connection */
+func (peer *Peer) sendHello(conn net.Conn) {
+	hello := &HelloMsg{Type: "hello", ChainID: peer.blockchain.ChainID}
+	jsonString, _ := json.Marshal(hello)
+	conn.Write(jsonString)
+}
+
+/* Check that a message is a hello message from a peer on the same network */
+func (peer *Peer) checkHello(temp map[string]interface{}) bool {
+	jsonString, _ := json.Marshal(temp)
+	hello := &HelloMsg{}
+	if err := json.Unmarshal(jsonString, hello); err != nil {
+		return false
+	}
+	return hello.Type == "hello" && hello.ChainID == peer.blockchain.ChainID
+}
+
 /* Handle peer map method */
 func (peer *Peer) handlePeersMap(peersMap PeersMapMsg) {
 	/* If peer already has a map, return */
@@ -222,7 +470,7 @@ func (peer *Peer) handlePeersMap(peersMap PeersMapMsg) {
 	/* Otherwise store the received map */
 	peer.peers = peersMap
 	for _, publicKey := range peer.peers.PeersMap {
-		peer.ledger.Accounts[publicKey] = 1000000
+		peer.ledger.Accounts[peer.keyRegistry.Register(publicKey)] = 1000000
 	}
 
 	if peer.peers.PeersMap == nil {
@@ -266,30 +514,62 @@ func (peer *Peer) handleNewPeer(newPeer NewPeerMsg) {
 	/* If the peer is not in the local map of peers yet, add it to the map of peers  */
 	if _, is_found := peer.peers.PeersMap[newPeer.Address]; !is_found {
 		peer.peers.PeersMap[newPeer.Address] = newPeer.PublicKey
-		peer.ledger.Accounts[newPeer.PublicKey] = 1000000
+		peer.ledger.Accounts[peer.keyRegistry.Register(newPeer.PublicKey)] = 1000000
 	}
 }
 
 /* Handle transaction method */
 func (peer *Peer) handleSignedTransaction(signedTransaction ledger.SignedTransaction) {
-	validSignature := RSA.VerifySignature(signedTransaction.Transaction, signedTransaction.Signature, signedTransaction.Transaction.From)
+	jobs, err := peer.transactionVerificationJobs(signedTransaction, peer.ledger)
+	if err != nil {
+		fmt.Println("Invalid transaction. " + err.Error())
+		return
+	}
+	validSignature := peer.verifier.VerifyAll(jobs)
 
 	// if the transaction signature is valid
 	if validSignature {
-		if signedTransaction.Transaction.Amount < 1 {
-			fmt.Println("Invalid transaction. Transaction must send at least 1 AU to be valid.")
+		transaction := signedTransaction.Transaction
+		if transaction.ChainID != peer.blockchain.ChainID {
+			fmt.Println("Invalid transaction. Transaction was signed for another network.")
+			return
+		} else if address.Validate(transaction.To) != nil {
+			fmt.Println("Invalid transaction. Receiver " + transaction.To + " is not a valid address.")
+			return
+		} else if !validMemo(transaction) {
+			fmt.Println("Invalid transaction. Memo is not addressed to the receiver.")
+			return
+		} else if transaction.Fee < ledger.TRANSACTION_FEE {
+			fmt.Println("Invalid transaction. Transaction must pay a fee of at least " + strconv.Itoa(ledger.TRANSACTION_FEE) + " AU to be valid.")
+			return
+		} else if err := peer.ledger.CheckTransaction(transaction, peer.blockchain.ChainID); err != nil {
+			fmt.Println("Invalid transaction. " + err.Error())
+			return
+		} else if peer.voucherPending(transaction) {
+			fmt.Println("Invalid transaction. Voucher is already being redeemed by a pending transaction.")
+			return
+		} else if transaction.IsExpired(peer.blockchain.GetSlotNumber()) {
+			fmt.Println("Invalid transaction. Transaction expired after slot " + strconv.Itoa(transaction.ValidUntilSlot) + ".")
+			return
+		} else if transaction.Nonce < peer.ledger.GetNonce(transaction.From) {
+			fmt.Println("Invalid transaction. Nonce " + strconv.Itoa(transaction.Nonce) + " has already been used by the sender.")
 			return
-		} else if signedTransaction.Transaction.Amount > peer.ledger.Accounts[signedTransaction.Transaction.From] {
-			fmt.Println("Invalid transaction. Insufficient funds in the sender's account.")
 		}
 		// and if the transaction has not been seen before, then
 		if !peer.transactionSeen(signedTransaction) {
 			// add it to the list of transactions seen
 			peer.markTransactionAsSeen(signedTransaction)
 
+			// if it replaces a pending transaction with the same sender and nonce, it must pay a higher fee
+			if !peer.replacePendingTransaction(signedTransaction) {
+				return
+			}
+
 			// add to list of peer's pending transactions
-			peer.pendingTransactions[signedTransaction.Transaction.ID] = signedTransaction
-			fmt.Println("Peer [" + peer.address + "] received transaction " + signedTransaction.Transaction.ID)
+			peer.lock.Lock()
+			peer.pendingTransactions[transaction.ID] = signedTransaction
+			peer.lock.Unlock()
+			fmt.Println("Peer [" + peer.address + "] received transaction " + transaction.ID)
 			fmt.Println("Awaiting procecssing ...")
 
 			// and broadcast it
@@ -303,6 +583,88 @@ func (peer *Peer) handleSignedTransaction(signedTransaction ledger.SignedTransac
 	}
 }
 
+/* Get the public key of the sender of a transaction in a ledger state, either carried by the transaction or the current key of the account */
+func (peer *Peer) getSenderPublicKey(signedTransaction ledger.SignedTransaction, state *ledger.Ledger) (string, bool) {
+	if signedTransaction.PublicKey == "" {
+		if accountKey, rotated := state.GetAccountKey(signedTransaction.Transaction.From); rotated {
+			return accountKey, true
+		}
+		return peer.keyRegistry.Lookup(signedTransaction.Transaction.From)
+	}
+	if err := state.CheckSigningKey(signedTransaction.Transaction, signedTransaction.PublicKey); err != nil {
+		return "", false
+	}
+	peer.keyRegistry.Register(signedTransaction.PublicKey)
+	return signedTransaction.PublicKey, true
+}
+
+/* Verify the signatures of the transactions of the block of a slot in parallel, returning true only if all of them are valid */
+func (peer *Peer) verifyTransactionSignatures(transactions []ledger.SignedTransaction, slot int) bool {
+	// each transaction must be signed by a key of its sender as left by the recoveries completed in the slot and the
+	// transactions before it, so that a replaced key no longer signs, and an account created earlier in the block is known
+	working := peer.ledger.SlotState(slot)
+	jobs := make([]signature.VerificationJob, 0, len(transactions))
+	for _, signedTransaction := range transactions {
+		transactionJobs, err := peer.transactionVerificationJobs(signedTransaction, working)
+		if err != nil {
+			return false
+		}
+		jobs = append(jobs, transactionJobs...)
+		if working.CheckTransaction(signedTransaction.Transaction, peer.blockchain.ChainID) != nil {
+			return false
+		}
+		working.ExecuteTransaction(signedTransaction)
+	}
+	return peer.verifier.VerifyAll(jobs)
+}
+
+/* Make the signature checks of a transaction in a ledger state: one for its sender, or one per cosigner if the sender is a multisignature account */
+func (peer *Peer) transactionVerificationJobs(signedTransaction ledger.SignedTransaction, state *ledger.Ledger) ([]signature.VerificationJob, error) {
+	if policy, found := state.GetMultisigPolicy(signedTransaction.Transaction.From); found {
+		if err := policy.CheckSigners(signedTransaction.Signatures); err != nil {
+			return nil, err
+		}
+		jobs := make([]signature.VerificationJob, len(signedTransaction.Signatures))
+		for i, cosignature := range signedTransaction.Signatures {
+			jobs[i] = signature.VerificationJob{
+				Object:    signedTransaction.Transaction,
+				Signature: cosignature.Signature,
+				PublicKey: cosignature.PublicKey,
+				Scheme:    cosignature.Scheme,
+			}
+		}
+		return jobs, nil
+	}
+	senderPublicKey, found := peer.getSenderPublicKey(signedTransaction, state)
+	if !found {
+		return nil, errors.New("public key of sender " + signedTransaction.Transaction.From + " is unknown")
+	}
+	return []signature.VerificationJob{{
+		Object:    signedTransaction.Transaction,
+		Signature: signedTransaction.Signature,
+		PublicKey: senderPublicKey,
+		Scheme:    signedTransaction.Scheme,
+	}}, nil
+}
+
+/* Evict the pending transaction with the same sender and nonce if the replacement pays enough, otherwise return false */
+func (peer *Peer) replacePendingTransaction(replacement ledger.SignedTransaction) bool {
+	peer.lock.Lock()
+	defer peer.lock.Unlock()
+	for id, pending := range peer.pendingTransactions {
+		if pending.Transaction.From != replacement.Transaction.From || pending.Transaction.Nonce != replacement.Transaction.Nonce {
+			continue
+		}
+		if !ledger.CanReplace(pending.Transaction, replacement.Transaction, peer.minFeeIncrement) {
+			fmt.Println("Replacement of transaction " + id + " rejected. Fee must be at least " + strconv.Itoa(pending.Transaction.Fee+peer.minFeeIncrement) + " AU.")
+			return false
+		}
+		fmt.Println("Peer [" + peer.address + "] replaced transaction " + id + " with " + replacement.Transaction.ID)
+		delete(peer.pendingTransactions, id)
+	}
+	return true
+}
+
 /* Handle block method */
 func (peer *Peer) handleSignedBlock(signedBlock blockchain.SignedBlock) {
 	signedBlock.BlockLock.Lock()
@@ -316,24 +678,66 @@ func (peer *Peer) handleSignedBlock(signedBlock blockchain.SignedBlock) {
 		// then verify that the draw is valid and is really a winner
 		senderPublicKey := signedBlock.Block.Vk
 		senderAddress := peer.peers.getAddressForPublicKey(senderPublicKey)
-		ticketsOfWinner := peer.ledger.Accounts[senderPublicKey]
-		valid := blockchain.VerifyWinner(signedBlock.Block.Draw, ticketsOfWinner, peer.blockchain.Hardness, senderPublicKey, peer.blockchain.Seed, signedBlock.Block.Slot)
+		peer.keyRegistry.Register(senderPublicKey)
+		// the block plays with the stake of the account it is created for, which its key must be allowed to sign for
+		// with the keys as they are in its slot, after the recoveries whose time-lock has passed
+		slotState := peer.ledger.SlotState(signedBlock.Block.Slot)
+		senderAccount, err := slotState.ValidatorAccount(signedBlock.Block.Account, senderPublicKey)
+		if err != nil {
+			fmt.Println("Block from peer [" + senderAddress + "] is invalid: " + err.Error())
+			senderAccount = address.FromPublicKey(senderPublicKey)
+		}
+		ticketsOfWinner := peer.ledger.Accounts[senderAccount]
+		valid := err == nil && blockchain.VerifyWinner(signedBlock.Block.Draw, ticketsOfWinner, peer.blockchain.Hardness, senderPublicKey, peer.blockchain.ChainID, peer.blockchain.Seed, signedBlock.Block.Slot)
+		if !blockchain.VerifyBlockSignature(&signedBlock) {
+			fmt.Println("Block from peer [" + senderAddress + "] has an invalid signature.")
+			valid = false
+		}
+		if !peer.verifyTransactionSignatures(signedBlock.Block.BlockData, signedBlock.Block.Slot) {
+			fmt.Println("Block from peer [" + senderAddress + "] contains transactions with invalid signatures.")
+			valid = false
+		}
+		if err := peer.ledger.CheckBlock(signedBlock.Block.BlockData, peer.blockchain.ChainID, signedBlock.Block.Slot); err != nil {
+			fmt.Println("Block from peer [" + senderAddress + "] contains an invalid transaction: " + err.Error())
+			valid = false
+		}
+		if !blockchain.ValidateChainID(signedBlock.Block, peer.blockchain.ChainID) {
+			fmt.Println("Block from peer [" + senderAddress + "] belongs to another network.")
+			valid = false
+		}
+		if !peer.blockchain.ValidateBlockSize(signedBlock.Block) {
+			fmt.Println("Block from peer [" + senderAddress + "] exceeds the maximum block size.")
+			valid = false
+		}
+		// the slot is chosen by the creator, and time-locks count slots, so it must not run ahead of the clock or go back
+		if !peer.blockchain.ValidateSlot(signedBlock.Block, peer.ledger.GetSlot()) {
+			fmt.Println("Block from peer [" + senderAddress + "] has slot " + strconv.Itoa(signedBlock.Block.Slot) + ", which is in the future or not after the last block.")
+			valid = false
+		}
+		if !blockchain.ValidateTransactionExpiry(signedBlock.Block) {
+			fmt.Println("Block from peer [" + senderAddress + "] contains expired transactions.")
+			valid = false
+		}
 		if valid {
 			// if valid, append block to the blockchain
 			fmt.Println("Block from peer [" + senderAddress + "] was successfully verified.")
 			// TODO: append block to the blockchain
 
-			// execute the transactions in the block
+			// complete the recoveries whose time-lock has passed, then execute the transactions in the block
+			for _, account := range peer.ledger.StartSlot(signedBlock.Block.Slot) {
+				fmt.Println("Account " + account + " was recovered by its guardians and has a new key.")
+			}
 			peer.executeTransactions(signedBlock.Block.BlockData)
+			peer.warnPendingRecovery()
 
-			// and reward the creator of the block
-			reward := len(signedBlock.Block.BlockData) + 10
-			peer.ledger.Accounts[senderPublicKey] += reward
+			// and reward the creator of the block with the fees of the transactions
+			reward := ledger.TotalFees(signedBlock.Block.BlockData) + 10
+			peer.ledger.Accounts[senderAccount] += reward
 			fmt.Println("Peer [" + senderAddress + "] was rewarded " + strconv.Itoa(reward) + " AU")
 
 		} else {
-			fmt.Println("Block verification failed. Penalizing validator " + signedBlock.Block.Vk)
-			peer.ledger.Accounts[signedBlock.Block.Vk] -= 10
+			fmt.Println("Block verification failed. Penalizing validator " + senderAccount)
+			peer.ledger.Accounts[senderAccount] -= 10
 		}
 
 		jsonString, _ := json.Marshal(signedBlock)
@@ -348,7 +752,61 @@ func (peer *Peer) write() {
 	var amount string
 	var senderAddress string
 	var receiverAddress string
+	var fee string
 	for {
+		var action string
+		fmt.Println("Enter 'message' to send an encrypted message, 'issuer' to register a voucher issuer, 'redeem' to redeem a voucher,")
+		fmt.Println("'multisig' to create a multisignature account, 'propose' or 'cosign' to sign a transaction from one,")
+		fmt.Println("'consensus' to authorise a consensus key for the peer's account, 'rotate' or 'recovery' to replace the account key or register a recovery key,")
+		fmt.Println("'recover' to rotate the key of an account with its recovery key, 'guardians' to nominate the guardians of the peer's account,")
+		fmt.Println("'approve' to approve the recovery of an account as its guardian, 'cancel' to cancel a recovery of the peer's account,")
+		fmt.Println("'stats' to show the hits and misses of the signature verification cache, or leave empty to send a transaction: ")
+		fmt.Scanln(&action)
+		switch action {
+		case "message":
+			peer.writeEncryptedMessage()
+			continue
+		case "issuer":
+			peer.writeVoucherIssuer()
+			continue
+		case "redeem":
+			peer.writeRedemption()
+			continue
+		case "multisig":
+			peer.writeCreateMultisig()
+			continue
+		case "propose":
+			peer.writeMultisigProposal()
+			continue
+		case "cosign":
+			peer.writeCosignature()
+			continue
+		case "consensus":
+			peer.writeConsensusKey()
+			continue
+		case "rotate":
+			peer.writeKeyRotation()
+			continue
+		case "recovery":
+			peer.writeRecoveryKey()
+			continue
+		case "recover":
+			peer.writeRecovery()
+			continue
+		case "guardians":
+			peer.writeGuardians()
+			continue
+		case "approve":
+			peer.writeRecoveryApproval()
+			continue
+		case "cancel":
+			peer.writeCancelRecovery()
+			continue
+		case "stats":
+			fmt.Println("Signature verification cache: " + peer.verifier.Cache().Stats())
+			continue
+		}
+
 		/* Read transaction from user */
 		fmt.Println("Amount to send: ")
 		fmt.Scanln(&amount)
@@ -356,16 +814,51 @@ func (peer *Peer) write() {
 		fmt.Scanln(&senderAddress)
 		fmt.Println("Receiver's address: ")
 		fmt.Scanln(&receiverAddress)
+		fmt.Println("Fee: ")
+		fmt.Scanln(&fee)
+		/* A nonce that was already used replaces the pending transaction with that nonce */
+		var nonce string
+		fmt.Println("Nonce (leave empty for the next nonce): ")
+		fmt.Scanln(&nonce)
+		var validForSlots string
+		fmt.Println("Number of slots the transaction is valid for (leave empty for no expiry): ")
+		fmt.Scanln(&validForSlots)
+		fmt.Println("Memo for the receiver (leave empty for none): ")
+		memo := ReadLine()
 
 		/* Make transaction object from the details, */
+		receiverAccount, err := peer.getReceiverAccount(receiverAddress)
+		if err != nil {
+			fmt.Println("Receiver's address is invalid: " + err.Error())
+			continue
+		}
+		var encryptedMemo *encryption.Envelope
+		if memo != "" {
+			if encryptedMemo, err = peer.encryptTo(receiverAddress, memo); err != nil {
+				fmt.Println("Could not encrypt memo: " + err.Error())
+				continue
+			}
+		}
 		signedTransaction := &ledger.SignedTransaction{Type: "signedTransaction"}
 		signedTransaction.Transaction.ID = senderAddress + strconv.Itoa(i) + strconv.Itoa(rand.Intn(100))
-		signedTransaction.Transaction.From = peer.publicKey
-		signedTransaction.Transaction.To = peer.peers.PeersMap[receiverAddress]
+		signedTransaction.Transaction.ChainID = peer.blockchain.ChainID
+		signedTransaction.Transaction.From = peer.account
+		signedTransaction.Transaction.To = receiverAccount
 		signedTransaction.Transaction.Amount, _ = strconv.Atoi(amount)
+		signedTransaction.Transaction.Fee, _ = strconv.Atoi(fee)
+		signedTransaction.Transaction.Nonce = peer.getNextNonce(nonce)
+		if slots, err := strconv.Atoi(validForSlots); err == nil && slots > 0 {
+			signedTransaction.Transaction.ValidUntilSlot = peer.blockchain.GetSlotNumber() + slots
+		}
+		signedTransaction.Transaction.Memo = encryptedMemo
 
-		/* Generate RSA signature for the transaction using the private key of the sender, */
-		signedTransaction.Signature = RSA.GenerateSignature(signedTransaction.Transaction, peer.privateKey)
+		/* Generate signature for the transaction using the private key of the sender, */
+		signedTransaction.PublicKey = peer.publicKey
+		signedTransaction.Scheme, signedTransaction.Signature, err = peer.signer.Sign(signedTransaction.Transaction)
+		if err != nil {
+			fmt.Println("Could not sign transaction: " + err.Error())
+			continue
+		}
 
 		/* and broadcast it */
 		jsonString, _ := json.Marshal(signedTransaction)
@@ -374,6 +867,30 @@ func (peer *Peer) write() {
 	}
 }
 
+/* Get the account a transaction is sent to, given either the address of a peer on the network or an account address */
+func (peer *Peer) getReceiverAccount(receiverAddress string) (string, error) {
+	if publicKey, found := peer.peers.PeersMap[receiverAddress]; found {
+		return address.FromPublicKey(publicKey), nil
+	}
+	if err := address.Validate(receiverAddress); err != nil {
+		return "", err
+	}
+	return receiverAddress, nil
+}
+
+/* Get the nonce of a transaction written by the peer, either given by the user or the next unused one */
+func (peer *Peer) getNextNonce(nonceString string) int {
+	if nonce, err := strconv.Atoi(nonceString); err == nil {
+		return nonce
+	}
+	if ledgerNonce := peer.ledger.GetNonce(peer.account); ledgerNonce > peer.nextNonce {
+		peer.nextNonce = ledgerNonce
+	}
+	nonce := peer.nextNonce
+	peer.nextNonce++
+	return nonce
+}
+
 /* Broadcast method */
 func (peer *Peer) broadcastMsg() {
 	for {
@@ -388,14 +905,14 @@ func (peer *Peer) broadcastMsg() {
 func (peer *Peer) printDetails() {
 	ip, port, _ := net.SplitHostPort(peer.ln.Addr().String())
 	fmt.Println("Listening on address " + ip + ":" + port)
-	fmt.Println("[" + peer.address + "], publicKey=" + peer.publicKey)
+	fmt.Println("[" + peer.address + "], account=" + peer.account)
 }
 
 /* Print map of peers and their public keys */
 func (peer *Peer) printPeersMap() {
 	fmt.Println("Peer map:")
 	for k, v := range peer.peers.PeersMap {
-		fmt.Println("Public key of [" + k + "]:" + v)
+		fmt.Println("Account of [" + k + "]:" + address.FromPublicKey(v))
 	}
 }
 
@@ -441,6 +958,8 @@ func (peer *Peer) markBlockAsSeen(signedBlock blockchain.SignedBlock) {
 
 /* Get peer's pending transactions list*/
 func (peer *Peer) getPendingTransactions() []ledger.SignedTransaction {
+	peer.lock.Lock()
+	defer peer.lock.Unlock()
 	pendingTransactions := make([]ledger.SignedTransaction, 0)
 	for _, transaction := range peer.pendingTransactions {
 		pendingTransactions = append(pendingTransactions, transaction)
@@ -451,11 +970,39 @@ func (peer *Peer) getPendingTransactions() []ledger.SignedTransaction {
 /* Remove a transaction from the peer's pending transactions list*/
 func (peer *Peer) removeFromPendingTransactions(signedTransaction ledger.SignedTransaction) {
 	peer.lock.Lock()
+	defer peer.lock.Unlock()
 	_, exists := peer.pendingTransactions[signedTransaction.Transaction.ID]
 	if exists {
 		fmt.Println("Peer [" + peer.address + "] removed transaction " + signedTransaction.Transaction.ID + " from pending transaction list.")
 		delete(peer.pendingTransactions, signedTransaction.Transaction.ID)
-		peer.lock.Unlock()
+	}
+}
+
+/* Remove pending transactions that can no longer be included in a block of the given slot */
+func (peer *Peer) removeExpiredPendingTransactions(slot int) {
+	peer.lock.Lock()
+	defer peer.lock.Unlock()
+	for id, pending := range peer.pendingTransactions {
+		if pending.Transaction.IsExpired(slot) {
+			fmt.Println("Peer [" + peer.address + "] removed expired transaction " + id + " from pending transaction list.")
+			delete(peer.pendingTransactions, id)
+		}
+	}
+}
+
+/* Remove pending transactions with a nonce that the sender has already used, or redeeming a spent voucher */
+func (peer *Peer) removeStalePendingTransactions() {
+	peer.lock.Lock()
+	defer peer.lock.Unlock()
+	for id, pending := range peer.pendingTransactions {
+		stale := pending.Transaction.Nonce < peer.ledger.GetNonce(pending.Transaction.From)
+		if pending.Transaction.Kind == ledger.KIND_REDEEM_VOUCHER && peer.ledger.CheckRedemption(pending.Transaction, peer.blockchain.ChainID) != nil {
+			stale = true
+		}
+		if stale {
+			fmt.Println("Peer [" + peer.address + "] removed stale transaction " + id + " from pending transaction list.")
+			delete(peer.pendingTransactions, id)
+		}
 	}
 }
 
@@ -470,11 +1017,14 @@ func (peer *Peer) executeTransactions(transactions []ledger.SignedTransaction) {
 		// execute the transaction
 		peer.ledger.ExecuteTransaction(transaction)
 		fmt.Println("Peer [" + peer.address + "] executed transaction: " + transaction.Transaction.ID)
+		peer.printMemo(transaction.Transaction)
 
 		// and remove the transaction if it is in receiving peer's pending transactions list
 		// so that it is not sent twice (and all the transactions in the block are valid and not duplicated)
 		peer.removeFromPendingTransactions(transaction)
 	}
+	// pending transactions whose nonce was used by an executed transaction can never be included
+	peer.removeStalePendingTransactions()
 	if len(transactions) > 0 {
 		fmt.Println("Processed " + strconv.Itoa(len(transactions)) + " transactions")
 		// and print ledger after the transaction is executed
@@ -485,10 +1035,19 @@ func (peer *Peer) executeTransactions(transactions []ledger.SignedTransaction) {
 func (peer *Peer) playLottery() {
 	for {
 		slot := peer.blockchain.GetSlotNumber()
-		draw := blockchain.MakeDraw(peer.blockchain.Seed, slot, peer.privateKey)
-		tickets := peer.ledger.Accounts[peer.publicKey]
+		peer.removeExpiredPendingTransactions(slot)
+		draw, drawOutput, err := blockchain.MakeDraw(peer.blockchain.ChainID, peer.blockchain.Seed, slot, peer.signer)
+		if err != nil {
+			// without a draw the peer cannot win the slot
+			fmt.Println("Peer [" + peer.address + "] could not draw for slot " + strconv.Itoa(slot) + ": " + err.Error())
+		}
+		tickets := peer.ledger.GetBalance(peer.validatorAccount)
+		if _, err := peer.ledger.SlotState(slot).ValidatorAccount(peer.validatorAccount, peer.publicKey); err != nil {
+			fmt.Println("Peer [" + peer.address + "] cannot validate yet: " + err.Error())
+			tickets = 0
+		}
 		fmt.Println("Peer [" + peer.address + "] has " + strconv.Itoa(tickets) + " tickets for slot " + strconv.Itoa(slot))
-		drawIsWinner := blockchain.IsWinner(draw, tickets, peer.blockchain.Hardness)
+		drawIsWinner := blockchain.IsWinner(drawOutput, tickets, peer.blockchain.Hardness)
 		if drawIsWinner {
 			// if the peer wins the slot
 			fmt.Println("Draw is winner. Peer [" + peer.address + "] creating new block in slot " + strconv.Itoa(slot))
@@ -496,11 +1055,23 @@ func (peer *Peer) playLottery() {
 			// make a new block with unprocessed transactions
 			pendingTransactions := peer.getPendingTransactions()
 			fmt.Println(strconv.Itoa(len(pendingTransactions)) + " unprocessed transactions found.")
-			signedBlock := blockchain.MakeSignedBlock(slot, draw, peer.privateKey, peer.publicKey, pendingTransactions)
-
-			// transmit the new block
-			jsonString, _ := json.Marshal(signedBlock)
-			peer.broadcast <- jsonString
+			blockTransactions := blockchain.SelectTransactions(pendingTransactions, peer.ledger.GetNonce, peer.blockchain.MaxBlockBytes, peer.blockchain.MaxBlockTxs)
+			// transactions that were valid on their own may not be together, e.g. redemptions overdrawing their issuer
+			blockTransactions = peer.ledger.SelectValid(blockTransactions, peer.blockchain.ChainID, slot)
+			fmt.Println(strconv.Itoa(len(blockTransactions)) + " transactions selected for the block.")
+			// blocks of the key's own account leave the account out
+			blockAccount := ""
+			if !address.Matches(peer.validatorAccount, peer.publicKey) {
+				blockAccount = peer.validatorAccount
+			}
+			signedBlock, err := blockchain.MakeSignedBlock(peer.blockchain.ChainID, blockAccount, slot, draw, peer.signer, blockTransactions)
+			if err != nil {
+				fmt.Println("Peer [" + peer.address + "] could not sign block for slot " + strconv.Itoa(slot) + ": " + err.Error())
+			} else {
+				// transmit the new block
+				jsonString, _ := json.Marshal(signedBlock)
+				peer.broadcast <- jsonString
+			}
 		}
 		time.Sleep(time.Duration(int64(peer.blockchain.SlotLengthSeconds) * int64(time.Second)))
 	}
@@ -0,0 +1,68 @@
+package peer
+
+import (
+	"fmt"
+	"math/rand"
+	"packages/address"
+	"packages/ledger"
+	"strconv"
+)
+
+/* Read a new key from the user and rotate the key of the peer's account to it */
+func (peer *Peer) writeKeyRotation() {
+	newKey, err := peer.readPublicKey("New account key")
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	var fee string
+	fmt.Println("Fee: ")
+	fmt.Scanln(&fee)
+	transaction := ledger.Transaction{Kind: ledger.KIND_ROTATE_KEY, To: peer.account, NewKey: newKey}
+	transaction.Fee, _ = strconv.Atoi(fee)
+	peer.signAndBroadcast(transaction)
+	fmt.Println("Once the rotation is in a block, restart the peer with the new key, giving account " + peer.account)
+}
+
+/* Read a recovery key from the user and register it for the peer's account */
+func (peer *Peer) writeRecoveryKey() {
+	recoveryKey, err := peer.readPublicKey("Recovery key")
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	var fee string
+	fmt.Println("Fee: ")
+	fmt.Scanln(&fee)
+	transaction := ledger.Transaction{Kind: ledger.KIND_REGISTER_RECOVERY_KEY, To: peer.account, RecoveryKey: recoveryKey}
+	transaction.Fee, _ = strconv.Atoi(fee)
+	peer.signAndBroadcast(transaction)
+}
+
+/* Rotate the key of an account the peer's key is the recovery key of */
+func (peer *Peer) writeRecovery() {
+	var account, fee string
+	fmt.Println("Account to recover: ")
+	fmt.Scanln(&account)
+	if err := address.Validate(account); err != nil {
+		fmt.Println(account + " is not a valid address: " + err.Error())
+		return
+	}
+	if recoveryKey, found := peer.ledger.GetRecoveryKey(account); !found || recoveryKey != peer.publicKey {
+		fmt.Println("The peer's key is not the recovery key of account " + account)
+		return
+	}
+	newKey, err := peer.readPublicKey("New account key")
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	fmt.Println("Fee: ")
+	fmt.Scanln(&fee)
+	transaction := ledger.Transaction{Kind: ledger.KIND_ROTATE_KEY, From: account, To: account, NewKey: newKey}
+	transaction.ID = peer.address + transaction.Kind + strconv.Itoa(rand.Intn(1000000))
+	transaction.ChainID = peer.blockchain.ChainID
+	transaction.Nonce = peer.ledger.GetNonce(account)
+	transaction.Fee, _ = strconv.Atoi(fee)
+	peer.signAndBroadcastAs(transaction)
+}
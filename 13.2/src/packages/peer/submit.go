@@ -0,0 +1,38 @@
+package peer
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"packages/ledger"
+	"time"
+)
+
+const SUBMIT_TIMEOUT_SECONDS = 10
+
+/* Submit a signed transaction to a node, which verifies it and floods it to the network */
+func SubmitTransaction(nodeAddress string, signedTransaction ledger.SignedTransaction) error {
+	conn, err := net.DialTimeout("tcp", nodeAddress, SUBMIT_TIMEOUT_SECONDS*time.Second)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(SUBMIT_TIMEOUT_SECONDS * time.Second))
+
+	// the node introduces its network first, which must be the one the transaction was signed for
+	hello := &HelloMsg{}
+	if err := json.NewDecoder(conn).Decode(hello); err != nil {
+		return err
+	}
+	if hello.Type != "hello" || hello.ChainID != signedTransaction.Transaction.ChainID {
+		return errors.New("node is on another network than the transaction")
+	}
+	helloBytes, _ := json.Marshal(&HelloMsg{Type: "hello", ChainID: hello.ChainID})
+	if _, err := conn.Write(helloBytes); err != nil {
+		return err
+	}
+	signedTransaction.Type = "signedTransaction"
+	transactionBytes, _ := json.Marshal(signedTransaction)
+	_, err = conn.Write(transactionBytes)
+	return err
+}
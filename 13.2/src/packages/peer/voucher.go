@@ -0,0 +1,108 @@
+package peer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"packages/ledger"
+	"strconv"
+)
+
+/* Read a voucher issuer from the user and register it for the peer's account */
+func (peer *Peer) writeVoucherIssuer() {
+	var publicKeyFile, denomination, fee string
+	fmt.Println("Voucher public key file: ")
+	fmt.Scanln(&publicKeyFile)
+	fmt.Println("Denomination of the vouchers: ")
+	fmt.Scanln(&denomination)
+	fmt.Println("Fee: ")
+	fmt.Scanln(&fee)
+
+	publicKey, err := ioutil.ReadFile(publicKeyFile)
+	if err != nil {
+		fmt.Println("Could not read voucher public key: " + err.Error())
+		return
+	}
+	transaction := ledger.Transaction{Kind: ledger.KIND_REGISTER_VOUCHER_ISSUER, To: peer.account}
+	transaction.VoucherIssuer = &ledger.VoucherIssuer{PublicKey: string(publicKey)}
+	transaction.VoucherIssuer.Denomination, _ = strconv.Atoi(denomination)
+	transaction.Fee, _ = strconv.Atoi(fee)
+	peer.signAndBroadcast(transaction)
+}
+
+/* Read a voucher file from the user and redeem it for the peer's account */
+func (peer *Peer) writeRedemption() {
+	var voucherFile, fee string
+	fmt.Println("Voucher file: ")
+	fmt.Scanln(&voucherFile)
+	fmt.Println("Fee (paid out of the voucher): ")
+	fmt.Scanln(&fee)
+
+	voucherBytes, err := ioutil.ReadFile(voucherFile)
+	if err != nil {
+		fmt.Println("Could not read voucher: " + err.Error())
+		return
+	}
+	var held ledger.HeldVoucher
+	if err := json.Unmarshal(voucherBytes, &held); err != nil {
+		fmt.Println("Could not read voucher: " + err.Error())
+		return
+	}
+	voucher := &held.Voucher
+	peer.ledger.LedgerLock.Lock()
+	issuer, registered := peer.ledger.VoucherIssuers[voucher.Issuer]
+	peer.ledger.LedgerLock.Unlock()
+	if !registered {
+		fmt.Println("Voucher issuer " + voucher.Issuer + " is not registered.")
+		return
+	}
+	transaction := ledger.Transaction{Kind: ledger.KIND_REDEEM_VOUCHER, To: peer.account, Amount: issuer.Denomination, Voucher: voucher}
+	transaction.Fee, _ = strconv.Atoi(fee)
+	transaction = peer.prepareTransaction(transaction)
+	// the one-time key signs the complete transaction, so the redemption cannot be redirected
+	if err := held.SignRedemption(&transaction); err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+	peer.signAndBroadcastAs(transaction)
+}
+
+/* Sign a transaction of the peer's account with the next nonce, and broadcast it */
+func (peer *Peer) signAndBroadcast(transaction ledger.Transaction) {
+	peer.signAndBroadcastAs(peer.prepareTransaction(transaction))
+}
+
+/* Set the ID, chain ID, sender and next nonce of a transaction of the peer's account */
+func (peer *Peer) prepareTransaction(transaction ledger.Transaction) ledger.Transaction {
+	transaction.ID = peer.address + transaction.Kind + strconv.Itoa(rand.Intn(1000000))
+	transaction.ChainID = peer.blockchain.ChainID
+	transaction.From = peer.account
+	transaction.Nonce = peer.getNextNonce("")
+	return transaction
+}
+
+/* Sign a transaction with the peer's key, whichever account it is sent from, and broadcast it */
+func (peer *Peer) signAndBroadcastAs(transaction ledger.Transaction) {
+	signedTransaction := &ledger.SignedTransaction{Type: "signedTransaction", Transaction: transaction, PublicKey: peer.publicKey}
+	var err error
+	signedTransaction.Scheme, signedTransaction.Signature, err = peer.signer.Sign(signedTransaction.Transaction)
+	if err != nil {
+		fmt.Println("Could not sign transaction: " + err.Error())
+		return
+	}
+	jsonString, _ := json.Marshal(signedTransaction)
+	peer.broadcast <- jsonString
+}
+
+/* Check if a pending transaction of another sender already redeems the voucher of a transaction */
+func (peer *Peer) voucherPending(transaction ledger.Transaction) bool {
+	peer.lock.Lock()
+	defer peer.lock.Unlock()
+	for _, pending := range peer.pendingTransactions {
+		if pending.Transaction.From != transaction.From && ledger.RedeemSameVoucher(pending.Transaction, transaction) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+/**
+Every request is a single JSON object on a new connection, answered by a single JSON object.
+Requests are typed, so that a block can only be signed through a block request, which is
+subject to the double-sign protection of the signer. The signer only answers VRF draws and blocks:
+transactions and messages are signed and decrypted with the account key, not the consensus key.
+**/
+
+package remotesigner
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net"
+	"packages/blockchain"
+	"packages/signature"
+	"time"
+)
+
+const REQUEST_TIMEOUT_SECONDS = 30
+
+/* Request types */
+const REQUEST_PUBLIC_KEY = "publicKey"
+const REQUEST_VRF = "vrf"
+const REQUEST_BLOCK = "block"
+
+/* Request struct, sent by the peer to the signer */
+type Request struct {
+	Type  string
+	Alpha string            `json:",omitempty"` // Hex-encoded VRF input
+	Block *blockchain.Block `json:",omitempty"`
+}
+
+/* Response struct, sent by the signer to the peer */
+type Response struct {
+	Error     string `json:",omitempty"`
+	PublicKey string `json:",omitempty"`
+	Scheme    string `json:",omitempty"`
+	Signature string `json:",omitempty"`
+	Proof     string `json:",omitempty"` // Hex-encoded VRF proof
+	Output    string `json:",omitempty"` // Hex-encoded VRF output
+}
+
+/* Client struct, a signer whose private key is held by the signer process */
+type Client struct {
+	socketPath string
+	publicKey  string
+}
+
+/* Connect to the signer listening on a Unix socket */
+func Dial(socketPath string) (*Client, error) {
+	client := &Client{socketPath: socketPath}
+	response, err := client.request(Request{Type: REQUEST_PUBLIC_KEY})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := signature.ParseVerifier(response.PublicKey); err != nil {
+		return nil, err
+	}
+	client.publicKey = response.PublicKey
+	return client, nil
+}
+
+func (client *Client) request(request Request) (Response, error) {
+	var response Response
+	conn, err := net.DialTimeout("unix", client.socketPath, REQUEST_TIMEOUT_SECONDS*time.Second)
+	if err != nil {
+		return response, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(REQUEST_TIMEOUT_SECONDS * time.Second))
+	if err := json.NewEncoder(conn).Encode(request); err != nil {
+		return response, err
+	}
+	if err := json.NewDecoder(conn).Decode(&response); err != nil {
+		return response, err
+	}
+	if response.Error != "" {
+		return response, errors.New("signer refused: " + response.Error)
+	}
+	return response, nil
+}
+
+func (client *Client) KeyType() string    { return signature.KeyType(client.publicKey) }
+func (client *Client) PublicKey() string  { return client.publicKey }
+func (client *Client) PrivateKey() string { return "" }
+
+func (client *Client) Verify(templateObject interface{}, objectSignature string, scheme string) bool {
+	return signature.Verify(templateObject, objectSignature, client.publicKey, scheme)
+}
+
+/* Sign a block. Other objects are refused, since the signer only signs blocks */
+func (client *Client) Sign(templateObject interface{}) (string, string, error) {
+	block, ok := templateObject.(*blockchain.Block)
+	if !ok {
+		return "", "", errors.New("the signer only signs blocks")
+	}
+	response, err := client.request(Request{Type: REQUEST_BLOCK, Block: block})
+	return response.Scheme, response.Signature, err
+}
+
+/* Compute a VRF proof in the signer */
+func (client *Client) ProveVRF(alpha []byte) ([]byte, []byte, error) {
+	response, err := client.request(Request{Type: REQUEST_VRF, Alpha: hex.EncodeToString(alpha)})
+	if err != nil {
+		return nil, nil, err
+	}
+	proof, err := hex.DecodeString(response.Proof)
+	if err != nil {
+		return nil, nil, err
+	}
+	output, err := hex.DecodeString(response.Output)
+	return proof, output, err
+}
@@ -0,0 +1,124 @@
+package remotesigner
+
+import (
+	"encoding/json"
+	"net"
+	"packages/blockchain"
+	"packages/signature"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const TEST_CHAIN_ID = "test"
+
+func testServer(t *testing.T) (*Server, signature.Signer, string) {
+	signer, err := signature.GenerateSigner(signature.KEY_TYPE_ED25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stateFile := filepath.Join(t.TempDir(), "signer.json")
+	server, err := MakeServer(signer, stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return server, signer, stateFile
+}
+
+func testBlock(signer signature.Signer, slot int, draw string) *blockchain.Block {
+	return &blockchain.Block{Type: "block", ChainID: TEST_CHAIN_ID, Vk: signer.PublicKey(), Slot: slot, Draw: draw}
+}
+
+func signBlock(server *Server, block *blockchain.Block) error {
+	_, err := server.Handle(Request{Type: REQUEST_BLOCK, Block: block})
+	return err
+}
+
+/* A block is signed at most once per slot, and never for a slot before the last signed one */
+func TestDoubleSignProtection(t *testing.T) {
+	server, signer, _ := testServer(t)
+	if err := signBlock(server, testBlock(signer, 10, "a")); err != nil {
+		t.Fatal(err)
+	}
+	if err := signBlock(server, testBlock(signer, 10, "a")); err != nil {
+		t.Error("same block not signed again for the same slot: " + err.Error())
+	}
+	if err := signBlock(server, testBlock(signer, 10, "b")); err == nil {
+		t.Error("second block signed for the same slot")
+	}
+	if err := signBlock(server, testBlock(signer, 9, "a")); err == nil {
+		t.Error("block signed for an earlier slot")
+	}
+	if err := signBlock(server, testBlock(signer, 11, "b")); err != nil {
+		t.Error("block not signed for a later slot: " + err.Error())
+	}
+	other := testBlock(signer, 10, "a")
+	other.Vk = "ed25519:00"
+	if err := signBlock(server, other); err == nil {
+		t.Error("block of another key signed")
+	}
+}
+
+/* The high-water marks are reloaded from the state file after a restart */
+func TestHighWaterMarkPersisted(t *testing.T) {
+	server, signer, stateFile := testServer(t)
+	if err := signBlock(server, testBlock(signer, 10, "a")); err != nil {
+		t.Fatal(err)
+	}
+	restarted, err := MakeServer(signer, stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := signBlock(restarted, testBlock(signer, 10, "b")); err == nil {
+		t.Error("second block signed for the same slot after a restart")
+	}
+	if err := signBlock(restarted, testBlock(signer, 9, "a")); err == nil {
+		t.Error("block signed for an earlier slot after a restart")
+	}
+	if err := signBlock(restarted, testBlock(signer, 10, "a")); err != nil {
+		t.Error("same block not signed again after a restart: " + err.Error())
+	}
+}
+
+/* Over the socket, blocks are signed and requests of other types are refused */
+func TestServeSocket(t *testing.T) {
+	server, signer, _ := testServer(t)
+	socketPath := filepath.Join(t.TempDir(), "signer.sock")
+	go server.Serve(socketPath)
+	var client *Client
+	var err error
+	for attempt := 0; attempt < 100; attempt++ {
+		if client, err = Dial(socketPath); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.PublicKey() != signer.PublicKey() {
+		t.Error("signer answered with another public key")
+	}
+
+	block := testBlock(signer, 10, "a")
+	scheme, blockSignature, err := client.Sign(block)
+	if err != nil || !signature.Verify(block, blockSignature, signer.PublicKey(), scheme) {
+		t.Errorf("block not signed over the socket: %v", err)
+	}
+	if _, _, err := client.Sign(struct{ Amount int }{Amount: 5}); err == nil {
+		t.Error("client signed an object that is not a block")
+	}
+
+	for _, requestType := range []string{"transaction", "decrypt", ""} {
+		conn, err := net.Dial("unix", socketPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		json.NewEncoder(conn).Encode(Request{Type: requestType, Block: testBlock(signer, 11, "a")})
+		var response Response
+		if err := json.NewDecoder(conn).Decode(&response); err != nil || response.Error == "" || response.Signature != "" {
+			t.Errorf("request of type %q not refused: %+v, %v", requestType, response, err)
+		}
+		conn.Close()
+	}
+}
@@ -0,0 +1,184 @@
+package remotesigner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"packages/signature"
+	"packages/vrf"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+/* Highest block signed on a chain */
+type HighWaterMark struct {
+	Slot      int
+	BlockHash string // Hex-encoded SHA-256 hash of the JSON encoding of the block
+}
+
+/* Server struct, holding the private key and the high-water marks of the chains it signed blocks on */
+type Server struct {
+	signer    signature.Signer
+	stateFile string
+	marks     map[string]HighWaterMark // chain ID -> highest block signed
+	lock      sync.Mutex
+}
+
+/* Make a signer server, loading the high-water marks from its state file if it exists */
+func MakeServer(signer signature.Signer, stateFile string) (*Server, error) {
+	server := &Server{signer: signer, stateFile: stateFile, marks: make(map[string]HighWaterMark)}
+	stateBytes, err := ioutil.ReadFile(stateFile)
+	if os.IsNotExist(err) {
+		return server, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(stateBytes, &server.marks); err != nil {
+		return nil, errors.New("corrupt signer state " + stateFile + ": " + err.Error())
+	}
+	return server, nil
+}
+
+/* Answer requests on a Unix socket, accessible only by the owner of the process */
+func (server *Server) Serve(socketPath string) error {
+	// a socket left behind by a previous run would make listening fail
+	if _, err := os.Stat(socketPath); err == nil {
+		if conn, err := net.Dial("unix", socketPath); err == nil {
+			conn.Close()
+			return errors.New("another signer is listening on " + socketPath)
+		}
+		os.Remove(socketPath)
+	}
+	// the socket is created in a directory only the owner can enter, and moved into place once it is
+	// restricted, so other local users cannot connect in between
+	dir, err := ioutil.TempDir(filepath.Dir(socketPath), ".signer")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	privatePath := filepath.Join(dir, "socket")
+	ln, err := net.Listen("unix", privatePath)
+	if err != nil {
+		return err
+	}
+	defer ln.Close()
+	if err := os.Chmod(privatePath, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(privatePath, socketPath); err != nil {
+		return err
+	}
+	defer os.Remove(socketPath)
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return err
+		}
+		go server.serveConn(conn)
+	}
+}
+
+func (server *Server) serveConn(conn net.Conn) {
+	defer conn.Close()
+	var request Request
+	if err := json.NewDecoder(conn).Decode(&request); err != nil {
+		return
+	}
+	response, err := server.Handle(request)
+	if err != nil {
+		fmt.Println("Refused " + request.Type + " request: " + err.Error())
+		response = Response{Error: err.Error()}
+	}
+	json.NewEncoder(conn).Encode(response)
+}
+
+/* Answer a request */
+func (server *Server) Handle(request Request) (Response, error) {
+	switch request.Type {
+	case REQUEST_PUBLIC_KEY:
+		return Response{PublicKey: server.signer.PublicKey()}, nil
+	case REQUEST_VRF:
+		alpha, err := hex.DecodeString(request.Alpha)
+		if err != nil {
+			return Response{}, err
+		}
+		// VRF proofs are unique for an input, so they need no double-sign protection
+		proof, output, err := vrf.Prove(server.signer, alpha)
+		return Response{Proof: hex.EncodeToString(proof), Output: hex.EncodeToString(output)}, err
+	case REQUEST_BLOCK:
+		return server.signBlock(request)
+	default:
+		return Response{}, errors.New("unknown request type " + request.Type)
+	}
+}
+
+/* Sign a block, unless a different block was signed for the same or a later slot of its chain */
+func (server *Server) signBlock(request Request) (Response, error) {
+	block := request.Block
+	if block == nil {
+		return Response{}, errors.New("no block")
+	}
+	if block.Vk != server.signer.PublicKey() {
+		return Response{}, errors.New("block is not created by the key of the signer")
+	}
+	blockBytes, err := json.Marshal(block)
+	if err != nil {
+		return Response{}, err
+	}
+	hash := sha256.Sum256(blockBytes)
+	blockHash := hex.EncodeToString(hash[:])
+
+	server.lock.Lock()
+	defer server.lock.Unlock()
+	mark, found := server.marks[block.ChainID]
+	if found && block.Slot < mark.Slot {
+		return Response{}, errors.New("slot " + strconv.Itoa(block.Slot) + " is before the last signed slot " + strconv.Itoa(mark.Slot))
+	}
+	if found && block.Slot == mark.Slot && blockHash != mark.BlockHash {
+		return Response{}, errors.New("a different block was already signed for slot " + strconv.Itoa(block.Slot))
+	}
+	// the mark is persisted before the signature leaves the signer, so a crash cannot lead to a double sign
+	server.marks[block.ChainID] = HighWaterMark{Slot: block.Slot, BlockHash: blockHash}
+	if err := server.saveMarks(); err != nil {
+		if found {
+			server.marks[block.ChainID] = mark
+		} else {
+			delete(server.marks, block.ChainID)
+		}
+		return Response{}, err
+	}
+	scheme, blockSignature, err := server.signer.Sign(block)
+	return Response{Scheme: scheme, Signature: blockSignature}, err
+}
+
+/* Write the high-water marks to the state file, replacing it atomically */
+func (server *Server) saveMarks() error {
+	stateBytes, err := json.Marshal(server.marks)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(server.stateFile), filepath.Base(server.stateFile)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(stateBytes); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), server.stateFile)
+}
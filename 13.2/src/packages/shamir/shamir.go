@@ -0,0 +1,209 @@
+/**
+The secret is split into chunks of 64 bytes, and every chunk is shared with its own random
+polynomial of degree t-1 over the field of integers modulo the Mersenne prime 2^521 - 1.
+Every share also carries the SHA-256 hash of the secret, so a reconstruction from
+inconsistent shares is detected, and a checksum of its own contents.
+**/
+
+package shamir
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"strconv"
+)
+
+const CHUNK_SIZE = 64
+const SHARE_VERSION = 1
+
+/* The field prime 2^521 - 1 */
+var prime = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 521), big.NewInt(1))
+
+/* Share struct, as written to a share file */
+type Share struct {
+	Version      int
+	Index        int      // x-coordinate of the share (1..n)
+	Threshold    int      // Number of shares needed to reconstruct the secret
+	Total        int      // Number of shares the secret was split into
+	SecretLength int      // Length of the secret in bytes
+	SecretHash   string   // Hex-encoded SHA-256 hash of the secret
+	Values       []string // Hex-encoded y-coordinates, one per chunk of the secret
+	Checksum     string   // Hex-encoded SHA-256 hash of the fields above
+}
+
+/* Split a secret into n shares, any t of which reconstruct it */
+func Split(secret []byte, n int, t int) ([]Share, error) {
+	if t < 2 || n < t || n > 255 {
+		return nil, errors.New("threshold must be at least 2 and at most the number of shares (at most 255)")
+	}
+	if len(secret) == 0 {
+		return nil, errors.New("secret is empty")
+	}
+	secretHash := sha256.Sum256(secret)
+	shares := make([]Share, n)
+	for i := range shares {
+		shares[i] = Share{Version: SHARE_VERSION, Index: i + 1, Threshold: t, Total: n, SecretLength: len(secret), SecretHash: hex.EncodeToString(secretHash[:])}
+	}
+
+	for start := 0; start < len(secret); start += CHUNK_SIZE {
+		end := start + CHUNK_SIZE
+		if end > len(secret) {
+			end = len(secret)
+		}
+		/* Random polynomial with the chunk as constant term */
+		coefficients := make([]*big.Int, t)
+		coefficients[0] = new(big.Int).SetBytes(secret[start:end])
+		for j := 1; j < t; j++ {
+			coefficient, err := rand.Int(rand.Reader, prime)
+			if err != nil {
+				return nil, err
+			}
+			coefficients[j] = coefficient
+		}
+		for i := range shares {
+			y := evaluate(coefficients, big.NewInt(int64(shares[i].Index)))
+			shares[i].Values = append(shares[i].Values, hex.EncodeToString(y.Bytes()))
+		}
+	}
+	for i := range shares {
+		shares[i].Checksum = shares[i].computeChecksum()
+	}
+	return shares, nil
+}
+
+/* Reconstruct a secret from at least t shares. With more than t shares, every share must agree */
+func Combine(shares []Share) ([]byte, error) {
+	if len(shares) == 0 {
+		return nil, errors.New("no shares given")
+	}
+	first := shares[0]
+	seen := make(map[int]bool)
+	for _, share := range shares {
+		if err := share.Verify(); err != nil {
+			return nil, err
+		}
+		if share.Threshold != first.Threshold || share.Total != first.Total || share.SecretLength != first.SecretLength ||
+			share.SecretHash != first.SecretHash || len(share.Values) != len(first.Values) {
+			return nil, errors.New("share " + strconv.Itoa(share.Index) + " belongs to another secret")
+		}
+		if seen[share.Index] {
+			return nil, errors.New("share " + strconv.Itoa(share.Index) + " was given twice")
+		}
+		seen[share.Index] = true
+	}
+	if len(shares) < first.Threshold {
+		return nil, errors.New("need " + strconv.Itoa(first.Threshold) + " shares, got " + strconv.Itoa(len(shares)))
+	}
+
+	/* Reconstruct from every window of t consecutive shares, so that a share that was altered consistently is also caught */
+	var secret []byte
+	for start := 0; start+first.Threshold <= len(shares); start++ {
+		candidate, err := interpolateSecret(shares[start:start+first.Threshold], first.SecretLength)
+		if err != nil {
+			return nil, err
+		}
+		hash := sha256.Sum256(candidate)
+		if hex.EncodeToString(hash[:]) != first.SecretHash {
+			return nil, errors.New("shares are inconsistent, the reconstructed secret does not match its hash")
+		}
+		secret = candidate
+	}
+	return secret, nil
+}
+
+/* Check the version and checksum of a share */
+func (share Share) Verify() error {
+	if share.Version != SHARE_VERSION {
+		return errors.New("unsupported share version " + strconv.Itoa(share.Version))
+	}
+	if share.Checksum != share.computeChecksum() {
+		return errors.New("share " + strconv.Itoa(share.Index) + " is corrupt, checksum does not match")
+	}
+	return nil
+}
+
+/* Write a share to a file, readable only by its owner */
+func WriteShare(filename string, share Share) error {
+	shareBytes, err := json.MarshalIndent(share, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, shareBytes, 0600)
+}
+
+/* Read a share from a file */
+func ReadShare(filename string) (Share, error) {
+	var share Share
+	shareBytes, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return share, err
+	}
+	if err := json.Unmarshal(shareBytes, &share); err != nil {
+		return share, errors.New("invalid share file " + filename + ": " + err.Error())
+	}
+	return share, share.Verify()
+}
+
+func (share Share) computeChecksum() string {
+	withoutChecksum := share
+	withoutChecksum.Checksum = ""
+	shareBytes, err := json.Marshal(withoutChecksum)
+	if err != nil {
+		panic(err)
+	}
+	hash := sha256.Sum256(shareBytes)
+	return hex.EncodeToString(hash[:])
+}
+
+/* Lagrange interpolation at x = 0 of every chunk */
+func interpolateSecret(shares []Share, secretLength int) ([]byte, error) {
+	secret := make([]byte, 0, secretLength)
+	for chunk := range shares[0].Values {
+		result := new(big.Int)
+		for i, share := range shares {
+			y, ok := new(big.Int).SetString(share.Values[chunk], 16)
+			if !ok || y.Cmp(prime) >= 0 {
+				return nil, errors.New("share " + strconv.Itoa(share.Index) + " has an invalid value")
+			}
+			/* Lagrange basis polynomial at 0: prod_j x_j / (x_j - x_i) */
+			numerator, denominator := big.NewInt(1), big.NewInt(1)
+			xi := big.NewInt(int64(share.Index))
+			for j, other := range shares {
+				if i == j {
+					continue
+				}
+				xj := big.NewInt(int64(other.Index))
+				numerator.Mul(numerator, xj).Mod(numerator, prime)
+				denominator.Mul(denominator, new(big.Int).Sub(xj, xi)).Mod(denominator, prime)
+			}
+			term := new(big.Int).Mul(y, numerator)
+			term.Mul(term, new(big.Int).ModInverse(denominator, prime))
+			result.Add(result, term).Mod(result, prime)
+		}
+		chunkLength := CHUNK_SIZE
+		if remaining := secretLength - len(secret); remaining < CHUNK_SIZE {
+			chunkLength = remaining
+		}
+		if result.BitLen() > chunkLength*8 {
+			return nil, errors.New("shares are inconsistent, a reconstructed chunk is too large")
+		}
+		chunkBytes := make([]byte, chunkLength)
+		result.FillBytes(chunkBytes)
+		secret = append(secret, chunkBytes...)
+	}
+	return secret, nil
+}
+
+/* Evaluate a polynomial at x with Horner's method */
+func evaluate(coefficients []*big.Int, x *big.Int) *big.Int {
+	result := new(big.Int)
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		result.Mul(result, x).Add(result, coefficients[i]).Mod(result, prime)
+	}
+	return result
+}
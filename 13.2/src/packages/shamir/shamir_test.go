@@ -0,0 +1,112 @@
+package shamir
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"math/big"
+	"testing"
+)
+
+/* Secret of several chunks, the last one partial, starting with a zero byte */
+func testSecret(t *testing.T) []byte {
+	secret := make([]byte, 2*CHUNK_SIZE+22)
+	if _, err := rand.Read(secret[1:]); err != nil {
+		t.Fatal(err)
+	}
+	return secret
+}
+
+/* All subsets of k of the shares */
+func subsets(shares []Share, k int) [][]Share {
+	if k == 0 {
+		return [][]Share{{}}
+	}
+	if len(shares) < k {
+		return nil
+	}
+	result := make([][]Share, 0)
+	for _, rest := range subsets(shares[1:], k-1) {
+		result = append(result, append([]Share{shares[0]}, rest...))
+	}
+	return append(result, subsets(shares[1:], k)...)
+}
+
+/* Any t of the n shares reconstruct the secret, fewer do not */
+func TestSplitCombine(t *testing.T) {
+	secret := testSecret(t)
+	for _, parameters := range [][2]int{{2, 2}, {3, 2}, {5, 3}, {6, 6}} {
+		n, k := parameters[0], parameters[1]
+		shares, err := Split(secret, n, k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, subset := range subsets(shares, k) {
+			combined, err := Combine(subset)
+			if err != nil || !bytes.Equal(combined, secret) {
+				t.Errorf("(%v, %v): %v shares did not reconstruct the secret: %v", k, n, k, err)
+			}
+		}
+		if combined, err := Combine(shares); err != nil || !bytes.Equal(combined, secret) {
+			t.Errorf("(%v, %v): all shares did not reconstruct the secret: %v", k, n, err)
+		}
+		for _, subset := range subsets(shares, k-1) {
+			if _, err := Combine(subset); err == nil {
+				t.Errorf("(%v, %v): %v shares reconstructed the secret", k, n, k-1)
+			}
+		}
+	}
+}
+
+func TestSplitRejected(t *testing.T) {
+	secret := testSecret(t)
+	for _, parameters := range [][2]int{{3, 1}, {2, 3}, {256, 2}} {
+		if _, err := Split(secret, parameters[0], parameters[1]); err == nil {
+			t.Errorf("split into %v shares with threshold %v accepted", parameters[0], parameters[1])
+		}
+	}
+	if _, err := Split(nil, 3, 2); err == nil {
+		t.Error("empty secret accepted")
+	}
+}
+
+/* Altered, duplicated or mixed-up shares are refused instead of reconstructing a wrong secret */
+func TestCombineRejectsInconsistentShares(t *testing.T) {
+	secret := testSecret(t)
+	shares, err := Split(secret, 5, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := Split(testSecret(t), 5, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a share altered without updating its checksum
+	corrupt := shares[1]
+	corrupt.Values = append([]string{}, shares[1].Values...)
+	corrupt.Values[0] = hex.EncodeToString(big.NewInt(42).Bytes())
+
+	// a share altered consistently, with a new checksum
+	altered := corrupt
+	altered.Checksum = altered.computeChecksum()
+
+	// a share of another secret that claims to belong to this one
+	mixedUp := other[1]
+	mixedUp.SecretHash = shares[0].SecretHash
+	mixedUp.Checksum = mixedUp.computeChecksum()
+
+	for name, given := range map[string][]Share{
+		"corrupt share":           {shares[0], corrupt, shares[2]},
+		"altered share":           {shares[0], altered, shares[2]},
+		"altered share beyond t":  {shares[0], shares[2], shares[3], altered},
+		"share of another secret": {shares[0], other[1], shares[2]},
+		"mixed-up share":          {shares[0], mixedUp, shares[2]},
+		"share given twice":       {shares[0], shares[0], shares[2]},
+		"unsupported version":     {shares[0], {Version: 2}, shares[2]},
+	} {
+		if combined, err := Combine(given); err == nil {
+			t.Errorf("%v accepted, reconstructing the correct secret: %v", name, bytes.Equal(combined, secret))
+		}
+	}
+}
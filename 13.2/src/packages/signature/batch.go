@@ -0,0 +1,93 @@
+package signature
+
+import (
+	"runtime"
+	"sync"
+)
+
+/* A single signature check */
+type VerificationJob struct {
+	Object    interface{} // The signed object
+	Signature string
+	PublicKey string
+	Scheme    string
+}
+
+type verificationTask struct {
+	job    VerificationJob
+	result *bool
+	done   *sync.WaitGroup
+}
+
+/* Batch verifier struct, owning a fixed number of worker goroutines */
+type BatchVerifier struct {
+	tasks   chan verificationTask
+	workers int
+	cache   *VerificationCache // nil if verifications are not cached
+	once    sync.Once
+}
+
+/* Start a batch verifier with the given number of workers (one per CPU if workers < 1) */
+func MakeBatchVerifier(workers int) *BatchVerifier {
+	return MakeCachedBatchVerifier(workers, nil)
+}
+
+/* Start a batch verifier whose workers consult a verification cache */
+func MakeCachedBatchVerifier(workers int, cache *VerificationCache) *BatchVerifier {
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+	verifier := &BatchVerifier{tasks: make(chan verificationTask, workers), workers: workers, cache: cache}
+	for i := 0; i < workers; i++ {
+		go verifier.work()
+	}
+	return verifier
+}
+
+func (verifier *BatchVerifier) work() {
+	for task := range verifier.tasks {
+		if verifier.cache != nil {
+			*task.result = verifier.cache.Verify(task.job)
+		} else {
+			*task.result = Verify(task.job.Object, task.job.Signature, task.job.PublicKey, task.job.Scheme)
+		}
+		task.done.Done()
+	}
+}
+
+/* Number of workers of the verifier */
+func (verifier *BatchVerifier) Workers() int {
+	return verifier.workers
+}
+
+/* Verification cache of the verifier, nil if verifications are not cached */
+func (verifier *BatchVerifier) Cache() *VerificationCache {
+	return verifier.cache
+}
+
+/* Verify a batch of signatures in parallel, returning the result of each job in order */
+func (verifier *BatchVerifier) VerifyBatch(jobs []VerificationJob) []bool {
+	results := make([]bool, len(jobs))
+	var done sync.WaitGroup
+	done.Add(len(jobs))
+	for i := range jobs {
+		verifier.tasks <- verificationTask{job: jobs[i], result: &results[i], done: &done}
+	}
+	done.Wait()
+	return results
+}
+
+/* Verify a batch of signatures in parallel, returning true only if all of them are valid */
+func (verifier *BatchVerifier) VerifyAll(jobs []VerificationJob) bool {
+	for _, valid := range verifier.VerifyBatch(jobs) {
+		if !valid {
+			return false
+		}
+	}
+	return true
+}
+
+/* Stop the workers of the verifier. The verifier must not be used afterwards */
+func (verifier *BatchVerifier) Close() {
+	verifier.once.Do(func() { close(verifier.tasks) })
+}
@@ -0,0 +1,88 @@
+package signature_test
+
+import (
+	"packages/address"
+	"packages/ledger"
+	"packages/signature"
+	"runtime"
+	"strconv"
+	"testing"
+)
+
+const BENCHMARK_SIGNED_TRANSACTIONS = 1000 // distinct transactions signed, repeated to fill larger blocks
+
+var BENCHMARK_BLOCK_SIZES = []int{1000, 5000, 10000}
+
+/* Sign distinct transactions and repeat them to fill a block of the given size */
+func signedTransactionJobs(tb testing.TB, keyType string, signed int, blockSize int) []signature.VerificationJob {
+	signer, err := signature.GenerateSigner(keyType)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	from := address.FromPublicKey(signer.PublicKey())
+	signedJobs := make([]signature.VerificationJob, signed)
+	for i := range signedJobs {
+		transaction := ledger.Transaction{ID: strconv.Itoa(i), From: from, To: from, Amount: 1, Nonce: i, Fee: ledger.TRANSACTION_FEE}
+		scheme, sig, err := signer.Sign(transaction)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		signedJobs[i] = signature.VerificationJob{Object: transaction, Signature: sig, PublicKey: signer.PublicKey(), Scheme: scheme}
+	}
+	jobs := make([]signature.VerificationJob, blockSize)
+	for i := range jobs {
+		jobs[i] = signedJobs[i%len(signedJobs)]
+	}
+	return jobs
+}
+
+/* The verifier must report the result of each job in order, and reject a batch holding a single invalid signature */
+func TestVerifyBatch(t *testing.T) {
+	for _, keyType := range []string{signature.KEY_TYPE_RSA, signature.KEY_TYPE_ED25519} {
+		jobs := signedTransactionJobs(t, keyType, 20, 20)
+		forged := 7
+		jobs[forged].Object = ledger.Transaction{ID: "forged"}
+
+		verifier := signature.MakeBatchVerifier(4)
+		for i, valid := range verifier.VerifyBatch(jobs) {
+			if valid != (i != forged) {
+				t.Errorf("%v job %v: got valid = %v", keyType, i, valid)
+			}
+		}
+		if verifier.VerifyAll(jobs) {
+			t.Errorf("%v batch with a forged signature accepted", keyType)
+		}
+		if !verifier.VerifyAll(append(jobs[:forged:forged], jobs[forged+1:]...)) {
+			t.Errorf("%v valid batch rejected", keyType)
+		}
+		verifier.Close()
+	}
+}
+
+/* Compare verifying the transaction signatures of blocks one at a time and on the worker pool */
+func BenchmarkVerifyBlock(b *testing.B) {
+	for _, keyType := range []string{signature.KEY_TYPE_RSA, signature.KEY_TYPE_ED25519} {
+		signedJobs := signedTransactionJobs(b, keyType, BENCHMARK_SIGNED_TRANSACTIONS, BENCHMARK_BLOCK_SIZES[len(BENCHMARK_BLOCK_SIZES)-1])
+		for _, blockSize := range BENCHMARK_BLOCK_SIZES {
+			jobs := signedJobs[:blockSize]
+			b.Run(keyType+"/"+strconv.Itoa(blockSize)+"/sequential", func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					for _, job := range jobs {
+						signature.Verify(job.Object, job.Signature, job.PublicKey, job.Scheme)
+					}
+				}
+				b.ReportMetric(float64(b.N*blockSize)/b.Elapsed().Seconds(), "tx/s")
+			})
+			b.Run(keyType+"/"+strconv.Itoa(blockSize)+"/pool", func(b *testing.B) {
+				verifier := signature.MakeBatchVerifier(runtime.NumCPU())
+				defer verifier.Close()
+				for i := 0; i < b.N; i++ {
+					if !verifier.VerifyAll(jobs) {
+						b.Fatal("batch verification rejected a valid signature")
+					}
+				}
+				b.ReportMetric(float64(b.N*blockSize)/b.Elapsed().Seconds(), "tx/s")
+			})
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package signature
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/json"
+	"strconv"
+	"sync"
+)
+
+const VERIFICATION_CACHE_SIZE = 100000
+
+/* Verification cache struct. Only valid signatures are remembered, so an entry can never turn an invalid signature valid */
+type VerificationCache struct {
+	capacity int
+	entries  map[[sha256.Size]byte]*list.Element
+	order    *list.List // most recently used entry first
+	hits     uint64
+	misses   uint64
+	lock     sync.Mutex
+}
+
+/* Make a verification cache holding at most capacity entries */
+func MakeVerificationCache(capacity int) *VerificationCache {
+	if capacity < 1 {
+		capacity = VERIFICATION_CACHE_SIZE
+	}
+	return &VerificationCache{
+		capacity: capacity,
+		entries:  make(map[[sha256.Size]byte]*list.Element),
+		order:    list.New(),
+	}
+}
+
+/* Verify the signature of a job, consulting the cache first */
+func (cache *VerificationCache) Verify(job VerificationJob) bool {
+	key, err := cacheKey(job)
+	if err != nil {
+		return false
+	}
+	cache.lock.Lock()
+	if element, found := cache.entries[key]; found {
+		cache.order.MoveToFront(element)
+		cache.hits++
+		cache.lock.Unlock()
+		return true
+	}
+	cache.misses++
+	cache.lock.Unlock()
+
+	// verify outside the lock, so that workers can verify in parallel
+	if !Verify(job.Object, job.Signature, job.PublicKey, job.Scheme) {
+		return false
+	}
+	cache.add(key)
+	return true
+}
+
+func (cache *VerificationCache) add(key [sha256.Size]byte) {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	if _, found := cache.entries[key]; found {
+		return
+	}
+	cache.entries[key] = cache.order.PushFront(key)
+	if cache.order.Len() > cache.capacity {
+		oldest := cache.order.Back()
+		cache.order.Remove(oldest)
+		delete(cache.entries, oldest.Value.([sha256.Size]byte))
+	}
+}
+
+/* Number of verifications answered by the cache */
+func (cache *VerificationCache) Hits() uint64 {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	return cache.hits
+}
+
+/* Number of verifications not found in the cache */
+func (cache *VerificationCache) Misses() uint64 {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	return cache.misses
+}
+
+/* Number of verifications currently remembered */
+func (cache *VerificationCache) Len() int {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	return cache.order.Len()
+}
+
+/* Summary of the counters of the cache */
+func (cache *VerificationCache) Stats() string {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	return strconv.FormatUint(cache.hits, 10) + " hits, " + strconv.FormatUint(cache.misses, 10) + " misses, " + strconv.Itoa(cache.order.Len()) + " entries"
+}
+
+/* Key of a verification: hash of the message hash, the signature, the scheme and the public key */
+func cacheKey(job VerificationJob) ([sha256.Size]byte, error) {
+	message, err := json.Marshal(job.Object)
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	messageHash := sha256.Sum256(message)
+	hash := sha256.New()
+	hash.Write(messageHash[:])
+	// fields are length-prefixed so that they cannot run into each other
+	for _, field := range []string{job.Signature, job.Scheme, job.PublicKey} {
+		hash.Write([]byte(strconv.Itoa(len(field)) + ":" + field))
+	}
+	var key [sha256.Size]byte
+	copy(key[:], hash.Sum(nil))
+	return key, nil
+}
@@ -0,0 +1,59 @@
+package signature_test
+
+import (
+	"packages/signature"
+	"runtime"
+	"strconv"
+	"testing"
+)
+
+/* The cache must only remember valid signatures and evict the least recently used one */
+func TestVerificationCache(t *testing.T) {
+	signer, err := signature.GenerateSigner(signature.KEY_TYPE_ED25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jobs := make([]signature.VerificationJob, 3)
+	for i := range jobs {
+		scheme, sig, _ := signer.Sign("message " + strconv.Itoa(i))
+		jobs[i] = signature.VerificationJob{Object: "message " + strconv.Itoa(i), Signature: sig, PublicKey: signer.PublicKey(), Scheme: scheme}
+	}
+	forged := jobs[0]
+	forged.Object = "forged message"
+
+	check := func(name string, ok bool) {
+		if !ok {
+			t.Error(name + " failed")
+		}
+	}
+	cache := signature.MakeVerificationCache(2)
+	check("valid signature accepted", cache.Verify(jobs[0]) && cache.Verify(jobs[1]))
+	check("invalid signature rejected", !cache.Verify(forged) && !cache.Verify(forged))
+	check("invalid signature not cached", cache.Len() == 2 && cache.Hits() == 0)
+	check("cached signature hit", cache.Verify(jobs[0]) && cache.Hits() == 1)
+	cache.Verify(jobs[2]) // evicts jobs[1], the least recently used entry
+	check("least recently used entry evicted", cache.Len() == 2 && cache.Verify(jobs[0]) && cache.Hits() == 2)
+	check("evicted entry verified again", cache.Verify(jobs[1]) && cache.Hits() == 2 && cache.Misses() == 6)
+}
+
+/* Measure verifying the transaction signatures of a block a second time, as when it is received after its transactions */
+func BenchmarkVerifyBlockCached(b *testing.B) {
+	for _, keyType := range []string{signature.KEY_TYPE_RSA, signature.KEY_TYPE_ED25519} {
+		signedJobs := signedTransactionJobs(b, keyType, BENCHMARK_SIGNED_TRANSACTIONS, BENCHMARK_BLOCK_SIZES[len(BENCHMARK_BLOCK_SIZES)-1])
+		for _, blockSize := range BENCHMARK_BLOCK_SIZES {
+			jobs := signedJobs[:blockSize]
+			b.Run(keyType+"/"+strconv.Itoa(blockSize), func(b *testing.B) {
+				verifier := signature.MakeCachedBatchVerifier(runtime.NumCPU(), signature.MakeVerificationCache(signature.VERIFICATION_CACHE_SIZE))
+				defer verifier.Close()
+				verifier.VerifyAll(jobs)
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					if !verifier.VerifyAll(jobs) {
+						b.Fatal("cached verification rejected a valid signature")
+					}
+				}
+				b.ReportMetric(float64(b.N*blockSize)/b.Elapsed().Seconds(), "tx/s")
+			})
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package signature
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"packages/RSA"
+	"strings"
+)
+
+/* Encode the private key of a signer as a PKCS#8 PEM block */
+func EncodePrivateKeyPEM(signer Signer) (string, error) {
+	switch signer.KeyType() {
+	case KEY_TYPE_ED25519:
+		der, err := x509.MarshalPKCS8PrivateKey(signer.(*Ed25519Signer).privateKey)
+		if err != nil {
+			return "", err
+		}
+		return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})), nil
+	default:
+		return RSA.EncodePrivateKeyPEM(RSA.ToKey(signer.PrivateKey()), RSA.FORMAT_PKCS8)
+	}
+}
+
+/* Make a signer from a private key in PEM, or in the formats of MakeSigner */
+func ParsePrivateKey(keyString string) (Signer, error) {
+	trimmed := strings.TrimSpace(keyString)
+	if KeyType(trimmed) == KEY_TYPE_ED25519 {
+		return MakeSigner(trimmed, "")
+	}
+	block, _ := pem.Decode([]byte(trimmed))
+	if block != nil && block.Type == "PRIVATE KEY" {
+		if parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+			if privateKey, ok := parsed.(ed25519.PrivateKey); ok {
+				return &Ed25519Signer{publicKey: privateKey.Public().(ed25519.PublicKey), privateKey: privateKey}, nil
+			}
+		}
+	}
+	privateKey, err := RSA.ParseKey(trimmed)
+	if err != nil {
+		return nil, err
+	}
+	if !privateKey.IsPrivate() {
+		return nil, errors.New("key is not a private key")
+	}
+	publicKey := privateKey.PublicKey()
+	if publicKey.E_or_d == nil {
+		return nil, errors.New("legacy RSA private key does not hold its public exponent")
+	}
+	return &RSASigner{publicKey: publicKey.ToString(), privateKey: privateKey.ToString()}, nil
+}
+
+/* Fingerprint of a public key for display, the SHA-256 hash of its SubjectPublicKeyInfo encoding */
+func Fingerprint(publicKey string) (string, error) {
+	if KeyType(publicKey) == KEY_TYPE_RSA {
+		return RSA.Fingerprint(RSA.ToKey(publicKey))
+	}
+	keyBytes, err := hex.DecodeString(strings.TrimPrefix(publicKey, ed25519Tag))
+	if err != nil || len(keyBytes) != ed25519.PublicKeySize {
+		return "", errors.New("invalid Ed25519 public key")
+	}
+	der, err := x509.MarshalPKIXPublicKey(ed25519.PublicKey(keyBytes))
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(der)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(hash[:]), nil
+}
@@ -0,0 +1,175 @@
+/**
+Public keys are passed around as strings tagged with their key type. RSA keys keep
+the JSON encoding of RSA.Key without a tag, so existing accounts keep their addresses,
+while Ed25519 keys are encoded as "ed25519:" followed by the hex-encoded key.
+**/
+
+package signature
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"packages/RSA"
+	"strings"
+)
+
+const KEY_TYPE_RSA = "rsa"
+const KEY_TYPE_ED25519 = "ed25519"
+const SCHEME_ED25519 = "ed25519"
+const DEFAULT_KEY_TYPE = KEY_TYPE_RSA
+const RSA_KEY_BITS = 2048
+
+const ed25519Tag = KEY_TYPE_ED25519 + ":"
+
+/* Verifier interface, checking signatures made with the private key of a public key */
+type Verifier interface {
+	KeyType() string
+	PublicKey() string
+	Verify(templateObject interface{}, signature string, scheme string) bool
+}
+
+/* Signer interface, holding a private key */
+type Signer interface {
+	Verifier
+	PrivateKey() string
+	/* Sign an object with the default scheme of the key type */
+	Sign(templateObject interface{}) (scheme string, signature string, err error)
+}
+
+/* Get the key type of a public or private key from its tag */
+func KeyType(key string) string {
+	if strings.HasPrefix(key, ed25519Tag) {
+		return KEY_TYPE_ED25519
+	}
+	return KEY_TYPE_RSA
+}
+
+/* Generate a signer with a fresh key of the given type */
+func GenerateSigner(keyType string) (Signer, error) {
+	switch keyType {
+	case KEY_TYPE_RSA, "":
+		publicKey, privateKey, err := RSA.GenerateKey(RSA_KEY_BITS, RSA.DEFAULT_E)
+		if err != nil {
+			return nil, err
+		}
+		return &RSASigner{publicKey: publicKey.ToString(), privateKey: privateKey.ToString()}, nil
+	case KEY_TYPE_ED25519:
+		publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		return &Ed25519Signer{publicKey: publicKey, privateKey: privateKey}, nil
+	default:
+		return nil, errors.New("unknown key type " + keyType)
+	}
+}
+
+/* Make a signer from an encoded private and public key */
+func MakeSigner(privateKey string, publicKey string) (Signer, error) {
+	switch KeyType(privateKey) {
+	case KEY_TYPE_ED25519:
+		keyBytes, err := hex.DecodeString(strings.TrimPrefix(privateKey, ed25519Tag))
+		if err != nil || len(keyBytes) != ed25519.PrivateKeySize {
+			return nil, errors.New("invalid Ed25519 private key")
+		}
+		key := ed25519.PrivateKey(keyBytes)
+		return &Ed25519Signer{publicKey: key.Public().(ed25519.PublicKey), privateKey: key}, nil
+	default:
+		return &RSASigner{publicKey: publicKey, privateKey: privateKey}, nil
+	}
+}
+
+/* Make a verifier for an encoded public key */
+func ParseVerifier(publicKey string) (Verifier, error) {
+	switch KeyType(publicKey) {
+	case KEY_TYPE_ED25519:
+		keyBytes, err := hex.DecodeString(strings.TrimPrefix(publicKey, ed25519Tag))
+		if err != nil || len(keyBytes) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 public key")
+		}
+		return &Ed25519Signer{publicKey: ed25519.PublicKey(keyBytes)}, nil
+	default:
+		key := RSA.ToKey(publicKey)
+		if key.N == nil || key.E_or_d == nil {
+			return nil, errors.New("invalid RSA public key")
+		}
+		return &RSASigner{publicKey: publicKey}, nil
+	}
+}
+
+/* Verify a signature, dispatching on the type of the public key */
+func Verify(templateObject interface{}, signature string, publicKey string, scheme string) bool {
+	verifier, err := ParseVerifier(publicKey)
+	if err != nil {
+		return false
+	}
+	return verifier.Verify(templateObject, signature, scheme)
+}
+
+/* RSA signer struct */
+type RSASigner struct {
+	publicKey  string
+	privateKey string
+}
+
+func (signer *RSASigner) KeyType() string    { return KEY_TYPE_RSA }
+func (signer *RSASigner) PublicKey() string  { return signer.publicKey }
+func (signer *RSASigner) PrivateKey() string { return signer.privateKey }
+
+func (signer *RSASigner) Verify(templateObject interface{}, signature string, scheme string) bool {
+	// textbook signatures are malleable and can be forged for products of signed messages
+	if scheme != RSA.SCHEME_PSS && scheme != RSA.SCHEME_PKCS1V15 {
+		return false
+	}
+	return RSA.Verify(templateObject, signature, signer.publicKey, scheme)
+}
+
+func (signer *RSASigner) Sign(templateObject interface{}) (string, string, error) {
+	signature, err := RSA.Sign(templateObject, signer.privateKey, RSA.DEFAULT_SCHEME)
+	return RSA.DEFAULT_SCHEME, signature, err
+}
+
+/* Ed25519 signer struct. Objects are signed in their JSON encoding */
+type Ed25519Signer struct {
+	publicKey  ed25519.PublicKey
+	privateKey ed25519.PrivateKey
+}
+
+func (signer *Ed25519Signer) KeyType() string { return KEY_TYPE_ED25519 }
+
+func (signer *Ed25519Signer) PublicKey() string {
+	return ed25519Tag + hex.EncodeToString(signer.publicKey)
+}
+
+func (signer *Ed25519Signer) PrivateKey() string {
+	return ed25519Tag + hex.EncodeToString(signer.privateKey)
+}
+
+func (signer *Ed25519Signer) Verify(templateObject interface{}, signature string, scheme string) bool {
+	if scheme != SCHEME_ED25519 {
+		return false
+	}
+	signatureBytes, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	message, err := json.Marshal(templateObject)
+	if err != nil {
+		return false
+	}
+	return ed25519.Verify(signer.publicKey, message, signatureBytes)
+}
+
+func (signer *Ed25519Signer) Sign(templateObject interface{}) (string, string, error) {
+	if signer.privateKey == nil {
+		return "", "", errors.New("no Ed25519 private key")
+	}
+	message, err := json.Marshal(templateObject)
+	if err != nil {
+		return "", "", err
+	}
+	return SCHEME_ED25519, hex.EncodeToString(ed25519.Sign(signer.privateKey, message)), nil
+}
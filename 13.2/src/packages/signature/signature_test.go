@@ -0,0 +1,41 @@
+package signature
+
+import (
+	"packages/RSA"
+	"testing"
+)
+
+/* Signatures verify only with the scheme of the signer, and RSA signatures only with a padded scheme */
+func TestVerifySchemes(t *testing.T) {
+	object := struct{ Amount int }{Amount: 5}
+	for _, keyType := range []string{KEY_TYPE_RSA, KEY_TYPE_ED25519} {
+		signer, err := GenerateSigner(keyType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		scheme, signature, err := signer.Sign(object)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !Verify(object, signature, signer.PublicKey(), scheme) {
+			t.Error(keyType + " signature does not verify")
+		}
+		for _, otherScheme := range []string{"", RSA.SCHEME_TEXTBOOK, RSA.SCHEME_PSS, SCHEME_ED25519} {
+			if otherScheme != scheme && Verify(object, signature, signer.PublicKey(), otherScheme) {
+				t.Error(keyType + " signature accepted with scheme " + otherScheme)
+			}
+		}
+	}
+
+	signer, err := GenerateSigner(KEY_TYPE_RSA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	textbook, err := RSA.Sign(object, signer.PrivateKey(), RSA.SCHEME_TEXTBOOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Verify(object, textbook, signer.PublicKey(), RSA.SCHEME_TEXTBOOK) || Verify(object, textbook, signer.PublicKey(), "") {
+		t.Error("textbook RSA signature accepted")
+	}
+}
@@ -0,0 +1,179 @@
+/**
+The construction follows RFC 9381, section 5, with the try-and-increment encoding
+to the curve (section 5.4.1.1) and the Ed25519 key pair of the account as VRF key.
+The curve arithmetic is filippo.io/edwards25519, which multiplies by the secret
+scalar and the nonce in constant time.
+**/
+
+package vrf
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha512"
+	"errors"
+
+	"filippo.io/edwards25519"
+)
+
+const SUITE_ECVRF_EDWARDS25519_SHA512_TAI = 0x03
+
+const ECVRF_PROOF_SIZE = 80 // Gamma (32 bytes) || c (16 bytes) || s (32 bytes)
+
+const challengeLength = 16
+const encodeToCurveDomainSeparator = 0x01
+const challengeDomainSeparator = 0x02
+const proofToHashDomainSeparator = 0x03
+
+/* Compute the VRF proof and output of an input with an Ed25519 private key */
+func ProveECVRF(privateKey ed25519.PrivateKey, alpha []byte) ([]byte, []byte, error) {
+	if len(privateKey) != ed25519.PrivateKeySize {
+		return nil, nil, errors.New("invalid Ed25519 private key")
+	}
+	// the secret scalar and the nonce key are derived from the seed as in RFC 8032, section 5.1.5
+	hashedSeed := sha512.Sum512(privateKey.Seed())
+	x, err := edwards25519.NewScalar().SetBytesWithClamping(hashedSeed[:32])
+	if err != nil {
+		return nil, nil, err
+	}
+	publicKey := []byte(privateKey.Public().(ed25519.PublicKey))
+
+	H, err := encodeToCurve(publicKey, alpha)
+	if err != nil {
+		return nil, nil, err
+	}
+	hString := H.Bytes()
+	gamma := new(edwards25519.Point).ScalarMult(x, H)
+
+	nonceHash := sha512.New()
+	nonceHash.Write(hashedSeed[32:])
+	nonceHash.Write(hString)
+	k, err := edwards25519.NewScalar().SetUniformBytes(nonceHash.Sum(nil))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	kB := new(edwards25519.Point).ScalarBaseMult(k)
+	kH := new(edwards25519.Point).ScalarMult(k, H)
+	cBytes := challenge(publicKey, hString, gamma.Bytes(), kB.Bytes(), kH.Bytes())
+	s := edwards25519.NewScalar().MultiplyAdd(challengeScalar(cBytes), x, k)
+
+	proof := append(gamma.Bytes(), cBytes...)
+	proof = append(proof, s.Bytes()...)
+	output, err := ECVRFProofToHash(proof)
+	return proof, output, err
+}
+
+/* Verify a VRF proof of an input against an Ed25519 public key, returning the output if the proof is valid */
+func VerifyECVRF(publicKey ed25519.PublicKey, alpha []byte, proof []byte) ([]byte, error) {
+	Y, err := decodeECVRFKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	gamma, cBytes, s, err := decodeProof(proof)
+	if err != nil {
+		return nil, err
+	}
+	H, err := encodeToCurve(publicKey, alpha)
+	if err != nil {
+		return nil, err
+	}
+
+	// U = s B - c Y and V = s H - c Gamma, all public values, so variable time is fine
+	minusC := edwards25519.NewScalar().Negate(challengeScalar(cBytes))
+	U := new(edwards25519.Point).VarTimeDoubleScalarBaseMult(minusC, Y, s)
+	V := new(edwards25519.Point).VarTimeMultiScalarMult([]*edwards25519.Scalar{s, minusC}, []*edwards25519.Point{H, gamma})
+	if !bytes.Equal(challenge(publicKey, H.Bytes(), gamma.Bytes(), U.Bytes(), V.Bytes()), cBytes) {
+		return nil, errors.New("invalid VRF proof")
+	}
+	return ECVRFProofToHash(proof)
+}
+
+/* Decode a public key, rejecting keys of small order, which would let the prover choose the output */
+func decodeECVRFKey(publicKey ed25519.PublicKey) (*edwards25519.Point, error) {
+	Y, err := decodePoint(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	if new(edwards25519.Point).MultByCofactor(Y).Equal(edwards25519.NewIdentityPoint()) == 1 {
+		return nil, errors.New("public key has small order")
+	}
+	return Y, nil
+}
+
+/* Output of a VRF proof, the hash of the cofactor multiple of Gamma. Only meaningful once the proof has been verified */
+func ECVRFProofToHash(proof []byte) ([]byte, error) {
+	gamma, _, _, err := decodeProof(proof)
+	if err != nil {
+		return nil, err
+	}
+	h := sha512.New()
+	h.Write([]byte{SUITE_ECVRF_EDWARDS25519_SHA512_TAI, proofToHashDomainSeparator})
+	h.Write(new(edwards25519.Point).MultByCofactor(gamma).Bytes())
+	h.Write([]byte{0x00})
+	return h.Sum(nil), nil
+}
+
+/* Split a proof into Gamma, the encoded challenge c and s */
+func decodeProof(proof []byte) (*edwards25519.Point, []byte, *edwards25519.Scalar, error) {
+	if len(proof) != ECVRF_PROOF_SIZE {
+		return nil, nil, nil, errors.New("VRF proof has the wrong length")
+	}
+	gamma, err := decodePoint(proof[:32])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	s, err := edwards25519.NewScalar().SetCanonicalBytes(proof[32+challengeLength:])
+	if err != nil {
+		return nil, nil, nil, errors.New("VRF proof out of range")
+	}
+	return gamma, proof[32 : 32+challengeLength], s, nil
+}
+
+/* Decode a point as in RFC 8032, section 5.1.3, which rejects the non-canonical encodings the library accepts */
+func decodePoint(encoded []byte) (*edwards25519.Point, error) {
+	p, err := new(edwards25519.Point).SetBytes(encoded)
+	if err != nil || !bytes.Equal(p.Bytes(), encoded) {
+		return nil, errors.New("invalid curve point")
+	}
+	return p, nil
+}
+
+/* Hash an input to a point of the prime order subgroup by try-and-increment, salted with the public key */
+func encodeToCurve(salt []byte, alpha []byte) (*edwards25519.Point, error) {
+	for counter := 0; counter < 256; counter++ {
+		h := sha512.New()
+		h.Write([]byte{SUITE_ECVRF_EDWARDS25519_SHA512_TAI, encodeToCurveDomainSeparator})
+		h.Write(salt)
+		h.Write(alpha)
+		h.Write([]byte{byte(counter), 0x00})
+		H, err := decodePoint(h.Sum(nil)[:32])
+		if err == nil {
+			return new(edwards25519.Point).MultByCofactor(H), nil
+		}
+	}
+	// each attempt succeeds with probability about 1/2, so this is never reached in practice
+	return nil, errors.New("could not encode VRF input to the curve")
+}
+
+/* Challenge of the proof, the truncated hash of five points */
+func challenge(points ...[]byte) []byte {
+	h := sha512.New()
+	h.Write([]byte{SUITE_ECVRF_EDWARDS25519_SHA512_TAI, challengeDomainSeparator})
+	for _, p := range points {
+		h.Write(p)
+	}
+	h.Write([]byte{0x00})
+	return h.Sum(nil)[:challengeLength]
+}
+
+/* The challenge as a scalar, which it always fits in */
+func challengeScalar(c []byte) *edwards25519.Scalar {
+	padded := make([]byte, 32)
+	copy(padded, c)
+	scalar, err := edwards25519.NewScalar().SetCanonicalBytes(padded)
+	if err != nil {
+		panic(err)
+	}
+	return scalar
+}
@@ -0,0 +1,75 @@
+package vrf
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"packages/RSA"
+	"packages/signature"
+	"strings"
+)
+
+/* Prover interface, for signers that compute VRF proofs without exposing their private key */
+type Prover interface {
+	ProveVRF(alpha []byte) ([]byte, []byte, error)
+}
+
+/* Compute the VRF proof and output of an input with the private key of a signer */
+func Prove(signer signature.Signer, alpha []byte) ([]byte, []byte, error) {
+	if prover, ok := signer.(Prover); ok {
+		return prover.ProveVRF(alpha)
+	}
+	switch signer.KeyType() {
+	case signature.KEY_TYPE_ED25519:
+		privateKey, err := hex.DecodeString(strings.TrimPrefix(signer.PrivateKey(), signature.KEY_TYPE_ED25519+":"))
+		if err != nil {
+			return nil, nil, errors.New("invalid Ed25519 private key")
+		}
+		return ProveECVRF(ed25519.PrivateKey(privateKey), alpha)
+	case signature.KEY_TYPE_RSA:
+		privateKey, err := RSA.ParseKey(signer.PrivateKey())
+		if err != nil {
+			return nil, nil, err
+		}
+		return RSA.ProveVRF(privateKey, alpha)
+	default:
+		return nil, nil, errors.New("no VRF for key type " + signer.KeyType())
+	}
+}
+
+/* Check that an encoded public key may be used as VRF key, as the keys producing blocks must */
+func ValidateKey(publicKey string) error {
+	switch signature.KeyType(publicKey) {
+	case signature.KEY_TYPE_ED25519:
+		keyBytes, err := hex.DecodeString(strings.TrimPrefix(publicKey, signature.KEY_TYPE_ED25519+":"))
+		if err != nil || len(keyBytes) != ed25519.PublicKeySize {
+			return errors.New("invalid Ed25519 public key")
+		}
+		_, err = decodeECVRFKey(ed25519.PublicKey(keyBytes))
+		return err
+	default:
+		key, err := RSA.ParseKey(publicKey)
+		if err != nil {
+			return err
+		}
+		return RSA.ValidateVRFKey(key.PublicKey())
+	}
+}
+
+/* Verify a VRF proof of an input against an encoded public key, returning the output if the proof is valid */
+func Verify(publicKey string, alpha []byte, proof []byte) ([]byte, error) {
+	switch signature.KeyType(publicKey) {
+	case signature.KEY_TYPE_ED25519:
+		keyBytes, err := hex.DecodeString(strings.TrimPrefix(publicKey, signature.KEY_TYPE_ED25519+":"))
+		if err != nil || len(keyBytes) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 public key")
+		}
+		return VerifyECVRF(ed25519.PublicKey(keyBytes), alpha, proof)
+	default:
+		key, err := RSA.ParseKey(publicKey)
+		if err != nil {
+			return nil, err
+		}
+		return RSA.VerifyVRF(key.PublicKey(), alpha, proof)
+	}
+}
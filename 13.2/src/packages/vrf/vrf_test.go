@@ -0,0 +1,99 @@
+package vrf
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"packages/RSA"
+	"packages/signature"
+	"testing"
+)
+
+/* ECVRF-EDWARDS25519-SHA512-TAI example of RFC 9381, appendix B.3 */
+const ecvrfVectorSecretKey = "9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60"
+const ecvrfVectorProof = "8657106690b5526245a92b003bb079ccd1a92130477671f6fc01ad16f26f723f26f8a57ccaed74ee1b190bed1f479d9727d2d0f9b005a6e456a35d4fb0daab1268a1b0db10836d9826a528ca76567805"
+const ecvrfVectorOutput = "90cf1df3b703cce59e2a35b925d411164068269d7b2d29f3301c03dd757876ff66b71dda49d2de59d03450451af026798e8f81cd2e333de5cdf4f3e140fdd8ae"
+
+func TestECVRFVector(t *testing.T) {
+	seed, _ := hex.DecodeString(ecvrfVectorSecretKey)
+	privateKey := ed25519.NewKeyFromSeed(seed)
+	proof, output, err := ProveECVRF(privateKey, []byte{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hex.EncodeToString(proof) != ecvrfVectorProof || hex.EncodeToString(output) != ecvrfVectorOutput {
+		t.Fatal("proof or output differs from the test vector")
+	}
+	verified, err := VerifyECVRF(privateKey.Public().(ed25519.PublicKey), []byte{}, proof)
+	if err != nil || !bytes.Equal(verified, output) {
+		t.Fatal("test vector proof does not verify")
+	}
+}
+
+/* Proofs of both key types must verify, be unique and bind the input and the key */
+func TestProveVerify(t *testing.T) {
+	for _, keyType := range []string{signature.KEY_TYPE_RSA, signature.KEY_TYPE_ED25519} {
+		signer, err := signature.GenerateSigner(keyType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		other, err := signature.GenerateSigner(keyType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		input := []byte("lottery input")
+		proof, output, err := Prove(signer, input)
+		if err != nil {
+			t.Fatal(keyType + " proof: " + err.Error())
+		}
+		if verified, err := Verify(signer.PublicKey(), input, proof); err != nil || !bytes.Equal(verified, output) {
+			t.Error(keyType + " proof does not verify")
+		}
+		if again, _, _ := Prove(signer, input); !bytes.Equal(again, proof) {
+			t.Error(keyType + " proof is not unique")
+		}
+		if _, err := Verify(signer.PublicKey(), []byte("other input"), proof); err == nil {
+			t.Error(keyType + " proof accepted for another input")
+		}
+		if _, err := Verify(other.PublicKey(), input, proof); err == nil {
+			t.Error(keyType + " proof accepted for another key")
+		}
+		proof[len(proof)/2] ^= 1
+		if _, err := Verify(signer.PublicKey(), input, proof); err == nil {
+			t.Error(keyType + " tampered proof accepted")
+		}
+	}
+}
+
+/* RSA keys with a small exponent or modulus cannot be used as VRF keys, since their outputs could be chosen */
+func TestValidateKeyRejectsWeakRSAKeys(t *testing.T) {
+	for _, parameters := range [][2]int{{2048, 3}, {1024, RSA.DEFAULT_E}} {
+		publicKey, privateKey, err := RSA.GenerateKey(parameters[0], parameters[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ValidateKey(publicKey.ToString()); err == nil {
+			t.Errorf("%v-bit key with e = %v accepted", parameters[0], parameters[1])
+		}
+		if _, _, err := RSA.ProveVRF(privateKey, []byte("input")); err == nil {
+			t.Errorf("proof with a %v-bit key with e = %v computed", parameters[0], parameters[1])
+		}
+	}
+	signer, err := signature.GenerateSigner(signature.KEY_TYPE_RSA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateKey(signer.PublicKey()); err != nil {
+		t.Error("generated RSA key rejected: " + err.Error())
+	}
+}
+
+/* Points must be encoded canonically, so that a key or proof has a single encoding */
+func TestValidateKeyRejectsInvalidEd25519Keys(t *testing.T) {
+	nonCanonical := bytes.Repeat([]byte{0xff}, ed25519.PublicKeySize) // y ≥ p
+	for _, publicKey := range []string{"ed25519:00", "ed25519:" + hex.EncodeToString(nonCanonical)} {
+		if err := ValidateKey(publicKey); err == nil {
+			t.Error("invalid Ed25519 key " + publicKey + " accepted")
+		}
+	}
+}
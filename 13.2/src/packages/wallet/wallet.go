@@ -0,0 +1,172 @@
+/**
+Mnemonics and seeds follow BIP-39 with the English wordlist. Ed25519 keys are derived
+with SLIP-10 along the hardened path m/44'/9000'/index'/0'. RSA keys are derived from the
+SLIP-10 key at m/44'/9000'/index'/1', which seeds a deterministic random generator for the
+prime search, so the same mnemonic always gives the same RSA key.
+**/
+
+package wallet
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"packages/RSA"
+	"packages/signature"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const DEFAULT_ENTROPY_BITS = 128
+const SEED_ITERATIONS = 2048
+const COIN_TYPE = 9000
+const HARDENED = 1 << 31
+const RSA_KEY_BITS = 2048
+
+/* Generate a new mnemonic with the given number of bits of entropy (128 to 256, a multiple of 32) */
+func NewMnemonic(entropyBits int) (string, error) {
+	if entropyBits < 128 || entropyBits > 256 || entropyBits%32 != 0 {
+		return "", errors.New("entropy must be 128 to 256 bits, a multiple of 32")
+	}
+	entropy := make([]byte, entropyBits/8)
+	if _, err := rand.Read(entropy); err != nil {
+		return "", err
+	}
+	return EntropyToMnemonic(entropy)
+}
+
+/* Encode entropy as a mnemonic, appending the first bits of its SHA-256 hash as a checksum */
+func EntropyToMnemonic(entropy []byte) (string, error) {
+	entropyBits := len(entropy) * 8
+	if entropyBits < 128 || entropyBits > 256 || entropyBits%32 != 0 {
+		return "", errors.New("entropy must be 128 to 256 bits, a multiple of 32")
+	}
+	checksumBits := entropyBits / 32
+	hash := sha256.Sum256(entropy)
+
+	/* Entropy followed by checksum, as an integer of entropyBits + checksumBits bits */
+	data := new(big.Int).SetBytes(entropy)
+	data.Lsh(data, uint(checksumBits))
+	data.Or(data, big.NewInt(int64(hash[0]>>uint(8-checksumBits))))
+
+	/* Split into 11 bit word indices */
+	wordCount := (entropyBits + checksumBits) / 11
+	words := make([]string, wordCount)
+	mask := big.NewInt(2047)
+	for i := wordCount - 1; i >= 0; i-- {
+		words[i] = wordlist[new(big.Int).And(data, mask).Int64()]
+		data.Rsh(data, 11)
+	}
+	return strings.Join(words, " "), nil
+}
+
+/* Check the words and the checksum of a mnemonic, returning its entropy */
+func MnemonicToEntropy(mnemonic string) ([]byte, error) {
+	words := strings.Fields(mnemonic)
+	if len(words) < 12 || len(words) > 24 || len(words)%3 != 0 {
+		return nil, errors.New("mnemonic must have 12, 15, 18, 21 or 24 words")
+	}
+	data := new(big.Int)
+	for _, word := range words {
+		index, found := wordIndex[strings.ToLower(word)]
+		if !found {
+			return nil, errors.New("unknown word in mnemonic: " + word)
+		}
+		data.Lsh(data, 11)
+		data.Or(data, big.NewInt(int64(index)))
+	}
+	checksumBits := len(words) * 11 / 33
+	entropyBits := len(words)*11 - checksumBits
+	checksum := new(big.Int).And(data, big.NewInt(int64(1<<uint(checksumBits)-1)))
+	data.Rsh(data, uint(checksumBits))
+	entropy := make([]byte, entropyBits/8)
+	data.FillBytes(entropy)
+
+	hash := sha256.Sum256(entropy)
+	if checksum.Int64() != int64(hash[0]>>uint(8-checksumBits)) {
+		return nil, errors.New("invalid mnemonic checksum")
+	}
+	return entropy, nil
+}
+
+/* Derive the 64 byte seed of a mnemonic, optionally protected by a passphrase */
+func MnemonicToSeed(mnemonic string, passphrase string) ([]byte, error) {
+	if _, err := MnemonicToEntropy(mnemonic); err != nil {
+		return nil, err
+	}
+	normalized := strings.Join(strings.Fields(strings.ToLower(mnemonic)), " ")
+	return pbkdf2.Key([]byte(normalized), []byte("mnemonic"+passphrase), SEED_ITERATIONS, 64, sha512.New), nil
+}
+
+/* Derive the signer of the account with the given index */
+func DeriveSigner(seed []byte, keyType string, index int) (signature.Signer, error) {
+	if index < 0 || index >= HARDENED {
+		return nil, errors.New("invalid account index " + strconv.Itoa(index))
+	}
+	switch keyType {
+	case signature.KEY_TYPE_ED25519:
+		key := derivePath(seed, []uint32{44, COIN_TYPE, uint32(index), 0})
+		privateKey := ed25519.NewKeyFromSeed(key)
+		return signature.MakeSigner(signature.KEY_TYPE_ED25519+":"+hex.EncodeToString(privateKey), "")
+	case signature.KEY_TYPE_RSA, "":
+		key := derivePath(seed, []uint32{44, COIN_TYPE, uint32(index), 1})
+		publicKey, privateKey, err := RSA.GenerateKeyFromReader(newDRBG(key), RSA_KEY_BITS, RSA.DEFAULT_E)
+		if err != nil {
+			return nil, err
+		}
+		return signature.MakeSigner(privateKey.ToString(), publicKey.ToString())
+	default:
+		return nil, errors.New("unknown key type " + keyType)
+	}
+}
+
+/* SLIP-10 derivation of the Ed25519 curve along a path of hardened indices, returning the 32 byte key */
+func derivePath(seed []byte, path []uint32) []byte {
+	mac := hmac.New(sha512.New, []byte("ed25519 seed"))
+	mac.Write(seed)
+	I := mac.Sum(nil)
+	key, chainCode := I[:32], I[32:]
+	for _, index := range path {
+		data := make([]byte, 37)
+		copy(data[1:33], key)
+		binary.BigEndian.PutUint32(data[33:], index+HARDENED)
+		mac = hmac.New(sha512.New, chainCode)
+		mac.Write(data)
+		I = mac.Sum(nil)
+		key, chainCode = I[:32], I[32:]
+	}
+	return key
+}
+
+/* Deterministic random generator, producing HMAC-SHA256(key, counter) blocks */
+type drbg struct {
+	key     []byte
+	counter uint64
+	buffer  []byte
+}
+
+func newDRBG(key []byte) *drbg {
+	return &drbg{key: key}
+}
+
+func (generator *drbg) Read(p []byte) (int, error) {
+	for len(generator.buffer) < len(p) {
+		mac := hmac.New(sha256.New, generator.key)
+		counter := make([]byte, 8)
+		binary.BigEndian.PutUint64(counter, generator.counter)
+		mac.Write(counter)
+		generator.buffer = mac.Sum(generator.buffer)
+		generator.counter++
+	}
+	n := copy(p, generator.buffer)
+	generator.buffer = generator.buffer[n:]
+	return n, nil
+}
@@ -0,0 +1,90 @@
+package wallet
+
+import (
+	"bytes"
+	"encoding/hex"
+	"packages/signature"
+	"testing"
+)
+
+/* First test vector of BIP-39, as published by TREZOR */
+func TestMnemonicToSeed(t *testing.T) {
+	entropy := make([]byte, 16)
+	mnemonic, err := EntropyToMnemonic(entropy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mnemonic != "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about" {
+		t.Fatal("wrong mnemonic " + mnemonic)
+	}
+	decoded, err := MnemonicToEntropy(mnemonic)
+	if err != nil || !bytes.Equal(decoded, entropy) {
+		t.Errorf("mnemonic decoded to %x, %v", decoded, err)
+	}
+	seed, err := MnemonicToSeed(mnemonic, "TREZOR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hex.EncodeToString(seed) != "c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04" {
+		t.Errorf("wrong seed %x", seed)
+	}
+	if _, err := MnemonicToSeed("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon", ""); err == nil {
+		t.Error("mnemonic with a wrong checksum accepted")
+	}
+}
+
+/* Test vector 1 of SLIP-10 for ed25519 */
+func TestDerivePath(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	for _, vector := range []struct {
+		path []uint32
+		key  string
+	}{
+		{[]uint32{}, "2b4be7f19ee27bbf30c667b642d5f4aa69fd169872f8fc3059c08ebae2eb19e7"},
+		{[]uint32{0}, "68e0fe46dfb67e368c75379acec591dad19df3cde26e63b93a8e704f1dade7a3"},
+		{[]uint32{0, 1}, "b1d0bad404bf35da785a64ca1ac54b2617211d2777696fbffaf208f746ae84f2"},
+		{[]uint32{0, 1, 2}, "92a5b23c0b8a99e37d07df3fb9966917f5d06e02ddbd909c7e184371463e9fc9"},
+		{[]uint32{0, 1, 2, 2}, "30d1dc7e5fc04c31219ab25a27ae00b50f6fd66622f6e9c913253d6511d1e662"},
+		{[]uint32{0, 1, 2, 2, 1000000000}, "8f94d394a8e8fd6b1bc2f3f49f5c47e385281d5c17e65324b0f62483e37e8793"},
+	} {
+		if key := hex.EncodeToString(derivePath(seed, vector.path)); key != vector.key {
+			t.Errorf("key at %v is %v", vector.path, key)
+		}
+	}
+}
+
+/* Restoring from the mnemonic gives the same keys again, and different accounts get different keys */
+func TestRestoreSigners(t *testing.T) {
+	mnemonic, err := NewMnemonic(DEFAULT_ENTROPY_BITS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seed, err := MnemonicToSeed(mnemonic, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	restoredSeed, err := MnemonicToSeed(mnemonic, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, keyType := range []string{signature.KEY_TYPE_ED25519, signature.KEY_TYPE_RSA} {
+		signer, err := DeriveSigner(seed, keyType, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		restored, err := DeriveSigner(restoredSeed, keyType, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if signer.KeyType() != keyType || restored.PublicKey() != signer.PublicKey() || restored.PrivateKey() != signer.PrivateKey() {
+			t.Error(keyType + " key not restored from the mnemonic")
+		}
+		other, err := DeriveSigner(seed, keyType, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if other.PublicKey() == signer.PublicKey() {
+			t.Error(keyType + " key shared by two accounts")
+		}
+	}
+}
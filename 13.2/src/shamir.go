@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"packages/address"
+	"packages/keystore"
+	"packages/shamir"
+	"packages/signature"
+	"path/filepath"
+	"strconv"
+)
+
+const shamirUsage = "split <n> <t> <out dir> <key file> | split-keystore <n> <t> <out dir> <keystore dir> <name> | combine <key file> <share file>..."
+
+/* Run a Shamir command: split, split-keystore or combine */
+func runShamirCommand(args []string) {
+	requireArgs("shamir", args, 1, shamirUsage)
+	switch args[0] {
+	case "split":
+		requireArgs("shamir split", args[1:], 4, "<n> <t> <out dir> <key file>")
+		privateKey, err := ioutil.ReadFile(args[4])
+		exitOnError(err)
+		splitPrivateKey(string(privateKey), args[1], args[2], args[3])
+	case "split-keystore":
+		requireArgs("shamir split-keystore", args[1:], 5, "<n> <t> <out dir> <keystore dir> <name>")
+		store, err := keystore.Open(args[4])
+		exitOnError(err)
+		fmt.Println("Password:")
+		privateKey, _, err := store.Load(args[5], readPassword())
+		exitOnError(err)
+		splitPrivateKey(privateKey, args[1], args[2], args[3])
+	case "combine":
+		requireArgs("shamir combine", args[1:], 2, "<key file> <share file>...")
+		shares := make([]shamir.Share, 0)
+		for _, filename := range args[2:] {
+			share, err := shamir.ReadShare(filename)
+			exitOnError(err)
+			shares = append(shares, share)
+		}
+		privateKey, err := shamir.Combine(shares)
+		exitOnError(err)
+		signer, err := signature.ParsePrivateKey(string(privateKey))
+		exitOnError(err)
+		if _, err := os.Stat(args[1]); err == nil {
+			fmt.Println("Refusing to overwrite existing file " + args[1])
+			os.Exit(1)
+		}
+		exitOnError(ioutil.WriteFile(args[1], privateKey, 0600))
+		fmt.Println("Reconstructed key of account " + address.FromPublicKey(signer.PublicKey()) + " in " + args[1])
+	default:
+		fmt.Println("Unknown shamir command " + args[0])
+		os.Exit(1)
+	}
+}
+
+/* Split a private key into n share files, any t of which reconstruct it */
+func splitPrivateKey(privateKey string, nString string, tString string, outDir string) {
+	signer, err := signature.ParsePrivateKey(privateKey)
+	exitOnError(err)
+	n, err1 := strconv.Atoi(nString)
+	t, err2 := strconv.Atoi(tString)
+	if err1 != nil || err2 != nil {
+		fmt.Println("Number of shares and threshold must be integers")
+		os.Exit(1)
+	}
+	shares, err := shamir.Split([]byte(privateKey), n, t)
+	exitOnError(err)
+	exitOnError(os.MkdirAll(outDir, 0700))
+	for _, share := range shares {
+		filename := filepath.Join(outDir, "share-"+strconv.Itoa(share.Index)+".json")
+		exitOnError(shamir.WriteShare(filename, share))
+		fmt.Println("Wrote " + filename)
+	}
+	fmt.Printf("Split key of account %v into %v shares, any %v of which reconstruct it.\n", address.FromPublicKey(signer.PublicKey()), n, t)
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+	"packages/keystore"
+	"packages/remotesigner"
+	"packages/signature"
+)
+
+/* Run the signer: load a key from a keystore and answer signing requests on a Unix socket */
+func runSignerCommand(args []string) {
+	requireArgs("signer", args, 4, "<socket> <state file> <keystore dir> <name>")
+	store, err := keystore.Open(args[2])
+	exitOnError(err)
+	fmt.Println("Password:")
+	privateKey, publicKey, err := store.Load(args[3], readPassword())
+	exitOnError(err)
+	signer, err := signature.MakeSigner(privateKey, publicKey)
+	exitOnError(err)
+	server, err := remotesigner.MakeServer(signer, args[1])
+	exitOnError(err)
+	fingerprint, err := signature.Fingerprint(signer.PublicKey())
+	exitOnError(err)
+	fmt.Println("Signing with " + signer.KeyType() + " key " + fingerprint + " on " + args[0])
+	exitOnError(server.Serve(args[0]))
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"packages/address"
+	"packages/keystore"
+	"packages/ledger"
+	"packages/peer"
+	"packages/signature"
+	"strconv"
+	"strings"
+)
+
+const transactionUsage = "prepare <file> <chain ID> <from> <to> <amount> <fee> <nonce> [valid until slot] | prepare-consensus-key|prepare-rotate-key|prepare-recovery-key <file> <chain ID> <account> <public key file> <fee> <nonce> | prepare-guardians <file> <chain ID> <account> <threshold> <guardian,...> <fee> <nonce> | prepare-approve-recovery <file> <chain ID> <guardian> <account> <new public key file> <fee> <nonce> | prepare-cancel-recovery <file> <chain ID> <account> <fee> <nonce> | show <file> | sign <keystore dir> <name> <file> | submit <file> <node address>"
+
+/* Run a transaction command: prepare, prepare a key change or recovery, show, sign or submit */
+func runTransactionCommand(args []string) {
+	requireArgs("tx", args, 2, transactionUsage)
+	switch args[0] {
+	case "prepare":
+		requireArgs("tx prepare", args[1:], 7, "<file> <chain ID> <from> <to> <amount> <fee> <nonce> [valid until slot]")
+		transaction, err := parseTransactionArgs(args[2:])
+		exitOnError(err)
+		exitOnError(writeUnsignedEnvelope(args[1], transaction))
+	case "prepare-consensus-key", "prepare-rotate-key", "prepare-recovery-key":
+		requireArgs("tx "+args[0], args[1:], 6, "<file> <chain ID> <account> <public key file> <fee> <nonce>")
+		// the account sets a key for itself, so it is both sender and recipient and nothing is transferred
+		transaction, err := parseTransactionArgs([]string{args[2], args[3], args[3], "0", args[5], args[6]})
+		exitOnError(err)
+		publicKeyBytes, err := ioutil.ReadFile(args[4])
+		exitOnError(err)
+		publicKey := string(publicKeyBytes)
+		switch args[0] {
+		case "prepare-consensus-key":
+			transaction.Kind, transaction.ConsensusKey = ledger.KIND_REGISTER_CONSENSUS_KEY, publicKey
+		case "prepare-rotate-key":
+			transaction.Kind, transaction.NewKey = ledger.KIND_ROTATE_KEY, publicKey
+		case "prepare-recovery-key":
+			transaction.Kind, transaction.RecoveryKey = ledger.KIND_REGISTER_RECOVERY_KEY, publicKey
+		}
+		fmt.Println("Key " + address.FromPublicKey(publicKey))
+		exitOnError(writeUnsignedEnvelope(args[1], transaction))
+	case "prepare-guardians":
+		requireArgs("tx prepare-guardians", args[1:], 7, "<file> <chain ID> <account> <threshold> <guardian,...> <fee> <nonce>")
+		transaction, err := parseTransactionArgs([]string{args[2], args[3], args[3], "0", args[6], args[7]})
+		exitOnError(err)
+		threshold, err := strconv.Atoi(args[4])
+		exitOnError(err)
+		policy := &ledger.GuardianPolicy{Threshold: threshold, Guardians: strings.Split(args[5], ",")}
+		exitOnError(policy.Validate(transaction.From))
+		transaction.Kind, transaction.Guardians = ledger.KIND_SET_GUARDIANS, policy
+		exitOnError(writeUnsignedEnvelope(args[1], transaction))
+	case "prepare-approve-recovery":
+		requireArgs("tx prepare-approve-recovery", args[1:], 7, "<file> <chain ID> <guardian> <account> <new public key file> <fee> <nonce>")
+		// the guardian sends the approval, paying its fee
+		transaction, err := parseTransactionArgs([]string{args[2], args[3], args[4], "0", args[6], args[7]})
+		exitOnError(err)
+		newKey, err := ioutil.ReadFile(args[5])
+		exitOnError(err)
+		transaction.Kind, transaction.NewKey = ledger.KIND_APPROVE_RECOVERY, string(newKey)
+		fmt.Println("Key " + address.FromPublicKey(transaction.NewKey))
+		exitOnError(writeUnsignedEnvelope(args[1], transaction))
+	case "prepare-cancel-recovery":
+		requireArgs("tx prepare-cancel-recovery", args[1:], 5, "<file> <chain ID> <account> <fee> <nonce>")
+		transaction, err := parseTransactionArgs([]string{args[2], args[3], args[3], "0", args[4], args[5]})
+		exitOnError(err)
+		transaction.Kind = ledger.KIND_CANCEL_RECOVERY
+		exitOnError(writeUnsignedEnvelope(args[1], transaction))
+	case "show":
+		envelope, err := ledger.ReadEnvelope(args[1])
+		exitOnError(err)
+		fmt.Println(envelope.Transaction.Transaction.Describe())
+		if envelope.Transaction.Signature != "" {
+			fmt.Println("Signed by " + address.FromPublicKey(envelope.Transaction.PublicKey) + " (" + envelope.Transaction.Scheme + ")")
+		}
+		for _, cosignature := range envelope.Transaction.Signatures {
+			fmt.Println("Cosigned by " + address.FromPublicKey(cosignature.PublicKey) + " (" + cosignature.Scheme + ")")
+		}
+		if !envelope.IsSigned() {
+			fmt.Println("Not signed")
+		}
+	case "sign":
+		requireArgs("tx sign", args[1:], 3, "<keystore dir> <name> <file>")
+		envelope, err := ledger.ReadEnvelope(args[3])
+		exitOnError(err)
+		store, err := keystore.Open(args[1])
+		exitOnError(err)
+		fmt.Println("Password:")
+		privateKey, publicKey, err := store.Load(args[2], readPassword())
+		exitOnError(err)
+		signer, err := signature.MakeSigner(privateKey, publicKey)
+		exitOnError(err)
+		exitOnError(signEnvelope(&envelope, signer))
+		exitOnError(ledger.WriteEnvelope(args[3], envelope))
+		fmt.Println("Signed transaction written to " + args[3])
+	case "submit":
+		requireArgs("tx submit", args[1:], 2, "<file> <node address>")
+		envelope, err := ledger.ReadEnvelope(args[1])
+		exitOnError(err)
+		if !envelope.IsSigned() {
+			exitOnError(errors.New("transaction is not signed"))
+		}
+		exitOnError(peer.SubmitTransaction(args[2], envelope.Transaction))
+		fmt.Println("Transaction " + envelope.Transaction.Transaction.ID + " submitted to " + args[2])
+	default:
+		fmt.Println("Unknown tx command " + args[0])
+		os.Exit(1)
+	}
+}
+
+/* Write an unsigned transaction to a new envelope file */
+func writeUnsignedEnvelope(file string, transaction ledger.Transaction) error {
+	if _, err := os.Stat(file); err == nil {
+		return errors.New("refusing to overwrite existing file " + file)
+	}
+	if err := ledger.WriteEnvelope(file, ledger.MakeEnvelope(ledger.SignedTransaction{Transaction: transaction})); err != nil {
+		return err
+	}
+	fmt.Println("Unsigned " + transaction.Describe())
+	fmt.Println("written to " + file)
+	return nil
+}
+
+/* Make a transfer from the arguments of tx prepare */
+func parseTransactionArgs(args []string) (ledger.Transaction, error) {
+	transaction := ledger.Transaction{ChainID: args[0], From: args[1], To: args[2]}
+	for _, account := range []string{transaction.From, transaction.To} {
+		if err := address.Validate(account); err != nil {
+			return transaction, errors.New(account + " is not a valid address: " + err.Error())
+		}
+	}
+	numbers := make([]int, 0, 4)
+	for _, arg := range args[3:] {
+		number, err := strconv.Atoi(arg)
+		if err != nil {
+			return transaction, errors.New(arg + " is not a number")
+		}
+		numbers = append(numbers, number)
+	}
+	transaction.Amount, transaction.Fee, transaction.Nonce = numbers[0], numbers[1], numbers[2]
+	if len(numbers) > 3 {
+		transaction.ValidUntilSlot = numbers[3]
+	}
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return transaction, err
+	}
+	transaction.ID = transaction.From + hex.EncodeToString(id)
+	return transaction, nil
+}
+
+/* Sign the transaction of an envelope after the user has confirmed it, as its sender or as a cosigner of a multisignature account */
+func signEnvelope(envelope *ledger.TransactionEnvelope, signer signature.Signer) error {
+	transaction := envelope.Transaction.Transaction
+	cosigning := false
+	fmt.Println(transaction.Describe())
+	// a key other than the first key of the sender is either its rotated or recovery key, or a cosigner
+	if !address.Matches(transaction.From, signer.PublicKey()) && transaction.Kind != ledger.KIND_ROTATE_KEY {
+		fmt.Println("The key is not the first key of the sender. Sign as the sender's current key, or as a cosigner of a multisignature account? (sender/cosigner)")
+		switch peer.ReadLine() {
+		case "sender":
+		case "cosigner":
+			cosigning = true
+		default:
+			return errors.New("signing cancelled")
+		}
+	}
+	fmt.Println("Sign this transaction? (yes/no)")
+	if peer.ReadLine() != "yes" {
+		return errors.New("signing cancelled")
+	}
+	scheme, transactionSignature, err := signer.Sign(transaction)
+	if err != nil {
+		return err
+	}
+	if cosigning {
+		envelope.Transaction.Signatures = append(envelope.Transaction.Signatures, ledger.MultisigSignature{PublicKey: signer.PublicKey(), Scheme: scheme, Signature: transactionSignature})
+	} else {
+		envelope.Transaction.PublicKey = signer.PublicKey()
+		envelope.Transaction.Scheme = scheme
+		envelope.Transaction.Signature = transactionSignature
+	}
+	return nil
+}
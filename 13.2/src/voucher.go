@@ -0,0 +1,102 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"packages/RSA"
+	"packages/keystore"
+	"packages/ledger"
+)
+
+const voucherUsage = "newkey <keystore dir> <name> <public key file> | request <public key file> <issuer account> <chain ID> <request file> | sign <keystore dir> <name> <blinded message> | unblind <request file> <blind signature> <voucher file>"
+
+/* Voucher request struct, kept by the holder until the blind signature is unblinded */
+type voucherRequest struct {
+	IssuerPublicKey string
+	Issuer          string
+	ChainID         string
+	Serial          string
+	SerialKey       string // Hex-encoded seed of the one-time key whose public key is the serial
+	BlindingFactor  string // Hex-encoded blinding factor, which links the request to the voucher
+}
+
+/* Run a voucher command: newkey, request, sign or unblind */
+func runVoucherCommand(args []string) {
+	requireArgs("voucher", args, 1, voucherUsage)
+	switch args[0] {
+	case "newkey":
+		requireArgs("voucher newkey", args[1:], 3, "<keystore dir> <name> <public key file>")
+		store, err := keystore.Open(args[1])
+		exitOnError(err)
+		publicKey, privateKey, err := RSA.GenerateKey(2048, RSA.DEFAULT_E)
+		exitOnError(err)
+		password := readNewPassword()
+		exitOnError(store.Store(args[2], password, privateKey.ToString(), publicKey.ToString()))
+		exitOnError(ioutil.WriteFile(args[3], []byte(publicKey.ToString()), 0644))
+		fmt.Println("Stored voucher key " + args[2] + ". Register the public key in " + args[3] + " as voucher issuer from the paying account.")
+	case "request":
+		requireArgs("voucher request", args[1:], 4, "<public key file> <issuer account> <chain ID> <request file>")
+		publicKeyBytes, err := ioutil.ReadFile(args[1])
+		exitOnError(err)
+		publicKey, err := RSA.ParseKey(string(publicKeyBytes))
+		exitOnError(err)
+		serial, serialKey, err := ed25519.GenerateKey(rand.Reader)
+		exitOnError(err)
+		request := voucherRequest{IssuerPublicKey: string(publicKeyBytes), Issuer: args[2], ChainID: args[3], Serial: hex.EncodeToString(serial), SerialKey: hex.EncodeToString(serialKey.Seed())}
+		blinded, r, err := RSA.Blind(publicKey.PublicKey(), ledger.VoucherMessageBytes(request.ChainID, request.Issuer, request.Serial))
+		exitOnError(err)
+		request.BlindingFactor = r.Text(16)
+		requestBytes, _ := json.Marshal(request)
+		exitOnError(ioutil.WriteFile(args[4], requestBytes, 0600))
+		fmt.Println("Blinded message for the issuer:")
+		fmt.Println(blinded.Text(16))
+	case "sign":
+		requireArgs("voucher sign", args[1:], 3, "<keystore dir> <name> <blinded message>")
+		store, err := keystore.Open(args[1])
+		exitOnError(err)
+		fmt.Println("Password:")
+		privateKeyString, _, err := store.Load(args[2], readPassword())
+		exitOnError(err)
+		privateKey, err := RSA.ParseKey(privateKeyString)
+		exitOnError(err)
+		blinded, ok := new(big.Int).SetString(args[3], 16)
+		if !ok {
+			exitOnError(errors.New("blinded message must be hex-encoded"))
+		}
+		blindSignature, err := RSA.SignBlinded(blinded, privateKey)
+		exitOnError(err)
+		fmt.Println("Blind signature for the holder:")
+		fmt.Println(blindSignature.Text(16))
+	case "unblind":
+		requireArgs("voucher unblind", args[1:], 3, "<request file> <blind signature> <voucher file>")
+		requestBytes, err := ioutil.ReadFile(args[1])
+		exitOnError(err)
+		var request voucherRequest
+		exitOnError(json.Unmarshal(requestBytes, &request))
+		publicKey, err := RSA.ParseKey(request.IssuerPublicKey)
+		exitOnError(err)
+		blindSignature, ok1 := new(big.Int).SetString(args[2], 16)
+		r, ok2 := new(big.Int).SetString(request.BlindingFactor, 16)
+		if !ok1 || !ok2 {
+			exitOnError(errors.New("blind signature and blinding factor must be hex-encoded"))
+		}
+		voucherSignature, err := RSA.Unblind(publicKey.PublicKey(), ledger.VoucherMessageBytes(request.ChainID, request.Issuer, request.Serial), blindSignature, r)
+		exitOnError(err)
+		held := ledger.HeldVoucher{Voucher: ledger.Voucher{Issuer: request.Issuer, Serial: request.Serial, Signature: voucherSignature.Text(16)}, SerialKey: request.SerialKey}
+		voucherBytes, _ := json.Marshal(held)
+		exitOnError(ioutil.WriteFile(args[3], voucherBytes, 0600))
+		// the blinding factor is no longer needed, and would link the voucher to its request
+		os.Remove(args[1])
+		fmt.Println("Voucher written to " + args[3] + ". Whoever holds the file can redeem it.")
+	default:
+		fmt.Println("Unknown voucher command " + args[0])
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"packages/address"
+	"packages/keystore"
+	"packages/peer"
+	"packages/signature"
+	"packages/wallet"
+	"strconv"
+)
+
+/* Run a wallet command: new, accounts or restore */
+func runWalletCommand(args []string) {
+	requireArgs("wallet", args, 1, "new [12|15|18|21|24] | accounts <count> [rsa|ed25519] | restore <keystore dir> <count> [rsa|ed25519]")
+	switch args[0] {
+	case "new":
+		words := 12
+		if len(args) > 1 {
+			words, _ = strconv.Atoi(args[1])
+		}
+		mnemonic, err := wallet.NewMnemonic(words * 32 / 3)
+		exitOnError(err)
+		fmt.Println("Write down the mnemonic below. Anyone who knows it can spend from all accounts derived from it.")
+		fmt.Println(mnemonic)
+	case "accounts":
+		requireArgs("wallet accounts", args[1:], 1, "<count> [rsa|ed25519]")
+		count, keyType := parseAccountArgs(args[1], args[2:])
+		seed := readSeed()
+		for i := 0; i < count; i++ {
+			signer, err := wallet.DeriveSigner(seed, keyType, i)
+			exitOnError(err)
+			fmt.Println(strconv.Itoa(i) + ": " + address.FromPublicKey(signer.PublicKey()))
+		}
+	case "restore":
+		requireArgs("wallet restore", args[1:], 2, "<keystore dir> <count> [rsa|ed25519]")
+		store, err := keystore.Open(args[1])
+		exitOnError(err)
+		count, keyType := parseAccountArgs(args[2], args[3:])
+		seed := readSeed()
+		password := readNewPassword()
+		for i := 0; i < count; i++ {
+			signer, err := wallet.DeriveSigner(seed, keyType, i)
+			exitOnError(err)
+			name := keyType + "-account-" + strconv.Itoa(i)
+			account := address.FromPublicKey(signer.PublicKey())
+			if publicKey, err := store.PublicKey(name); err == nil {
+				if publicKey != signer.PublicKey() {
+					fmt.Println(name + " already exists in the keystore with another key, skipping.")
+				} else {
+					fmt.Println(name + " already restored: " + account)
+				}
+				continue
+			}
+			exitOnError(store.Store(name, password, signer.PrivateKey(), signer.PublicKey()))
+			fmt.Println(name + " restored: " + account)
+		}
+	default:
+		fmt.Println("Unknown wallet command " + args[0])
+		os.Exit(1)
+	}
+}
+
+/* Parse the number of accounts and the key type of a wallet command */
+func parseAccountArgs(countString string, rest []string) (int, string) {
+	count, err := strconv.Atoi(countString)
+	if err != nil || count < 1 {
+		fmt.Println("Invalid number of accounts " + countString)
+		os.Exit(1)
+	}
+	keyType := signature.KEY_TYPE_ED25519
+	if len(rest) > 0 {
+		keyType = rest[0]
+	}
+	return count, keyType
+}
+
+/* Read a mnemonic and its passphrase from the user and derive the seed */
+func readSeed() []byte {
+	fmt.Println("Mnemonic:")
+	mnemonic := peer.ReadLine()
+	fmt.Println("Passphrase (leave empty for none):")
+	passphrase := peer.ReadLine()
+	seed, err := wallet.MnemonicToSeed(mnemonic, passphrase)
+	exitOnError(err)
+	return seed
+}
@@ -112,17 +112,27 @@ func MeasureSpeed(data []byte, elapsed time.Duration) {
 }
 
 func TestSoftwareWallet() {
-	filename := "very secret filename"
+	keystoreDir := "keystore"
+	name := "very-secret-key"
 	password := "jqrhadgoyyodsrddqtio"
 	wrongPassword := "zpibmasocxjflfbfvccl"
 	msg := new(big.Int).SetInt64(80085)
-	publicKey := rsaexample.Generate(filename, password)
+	publicKey, err := rsaexample.Generate(keystoreDir, name, password)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
 
 	/* Successfuly create signature */
-	signature := rsaexample.Sign(filename, password, msg.Bytes())
+	signature, err := rsaexample.Sign(keystoreDir, name, password, msg.Bytes())
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
 	recoveredMsg := rsaexample.Decrypt(signature, publicKey)
 	fmt.Println("Recovered message: " + recoveredMsg.String())
 
-	/* Create signature unsuccessfully (wrong password, file with private key is not found) */
-	signature = rsaexample.Sign(filename, wrongPassword, msg.Bytes())
+	/* Create signature unsuccessfully (wrong password, the private key cannot be decrypted) */
+	_, err = rsaexample.Sign(keystoreDir, name, wrongPassword, msg.Bytes())
+	fmt.Println("Signing with wrong password: " + err.Error())
 }
@@ -0,0 +1,209 @@
+/**
+Every key is stored in its own file <name>.key in the keystore directory, readable only
+by its owner. The private key is encrypted with AES-256-GCM under a key derived from the
+password with scrypt, and the name and public key are authenticated along with it.
+**/
+
+package keystore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const KEY_FILE_EXTENSION = ".key"
+const KEY_FILE_VERSION = 1
+const SCRYPT_N = 1 << 15
+const SCRYPT_R = 8
+const SCRYPT_P = 1
+const SALT_LENGTH = 16
+
+var ErrWrongPassword = errors.New("wrong password")
+var ErrKeyNotFound = errors.New("key not found in keystore")
+var ErrKeyExists = errors.New("key already exists in keystore")
+
+var validName = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+/* Keystore struct */
+type Keystore struct {
+	Dir string
+}
+
+/* Key file struct, as stored on disk */
+type KeyFile struct {
+	Version    int
+	Name       string
+	PublicKey  string // Public key, readable without the password
+	KDF        KDFParams
+	Nonce      []byte // AES-GCM nonce
+	Ciphertext []byte // Encrypted private key
+}
+
+/* Parameters of the scrypt key derivation */
+type KDFParams struct {
+	N    int
+	R    int
+	P    int
+	Salt []byte
+}
+
+/* Open a keystore directory, creating it if it does not exist */
+func Open(dir string) (*Keystore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &Keystore{Dir: dir}, nil
+}
+
+/* Store a new private key under a name, encrypted with the password */
+func (keystore *Keystore) Store(name string, password string, privateKey string, publicKey string) error {
+	if !validName.MatchString(name) {
+		return errors.New("invalid key name " + name)
+	}
+	if _, err := os.Stat(keystore.path(name)); err == nil {
+		return ErrKeyExists
+	}
+	return keystore.write(name, password, privateKey, publicKey)
+}
+
+/* Load and decrypt the private key stored under a name, returning the private and public key */
+func (keystore *Keystore) Load(name string, password string) (string, string, error) {
+	keyFile, err := keystore.readKeyFile(name)
+	if err != nil {
+		return "", "", err
+	}
+	aead, err := makeAEAD(password, keyFile.KDF)
+	if err != nil {
+		return "", "", err
+	}
+	if len(keyFile.Nonce) != aead.NonceSize() {
+		return "", "", errors.New("corrupt key file " + keystore.path(name))
+	}
+	privateKey, err := aead.Open(nil, keyFile.Nonce, keyFile.Ciphertext, additionalData(keyFile.Name, keyFile.PublicKey))
+	if err != nil {
+		return "", "", ErrWrongPassword
+	}
+	return string(privateKey), keyFile.PublicKey, nil
+}
+
+/* Get the public key stored under a name, without the password */
+func (keystore *Keystore) PublicKey(name string) (string, error) {
+	keyFile, err := keystore.readKeyFile(name)
+	if err != nil {
+		return "", err
+	}
+	return keyFile.PublicKey, nil
+}
+
+/* Check if a key is stored under a name */
+func (keystore *Keystore) Has(name string) bool {
+	_, err := os.Stat(keystore.path(name))
+	return err == nil
+}
+
+/* List the names of the stored keys */
+func (keystore *Keystore) List() ([]string, error) {
+	files, err := ioutil.ReadDir(keystore.Dir)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0)
+	for _, file := range files {
+		if !file.IsDir() && strings.HasSuffix(file.Name(), KEY_FILE_EXTENSION) {
+			names = append(names, strings.TrimSuffix(file.Name(), KEY_FILE_EXTENSION))
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+/* Re-encrypt the key stored under a name with a new password */
+func (keystore *Keystore) ChangePassword(name string, oldPassword string, newPassword string) error {
+	privateKey, publicKey, err := keystore.Load(name, oldPassword)
+	if err != nil {
+		return err
+	}
+	return keystore.write(name, newPassword, privateKey, publicKey)
+}
+
+/* Encrypt a private key and write it to its key file, replacing the file atomically */
+func (keystore *Keystore) write(name string, password string, privateKey string, publicKey string) error {
+	keyFile := KeyFile{Version: KEY_FILE_VERSION, Name: name, PublicKey: publicKey}
+	keyFile.KDF = KDFParams{N: SCRYPT_N, R: SCRYPT_R, P: SCRYPT_P, Salt: make([]byte, SALT_LENGTH)}
+	if _, err := rand.Read(keyFile.KDF.Salt); err != nil {
+		return err
+	}
+	aead, err := makeAEAD(password, keyFile.KDF)
+	if err != nil {
+		return err
+	}
+	keyFile.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(keyFile.Nonce); err != nil {
+		return err
+	}
+	keyFile.Ciphertext = aead.Seal(nil, keyFile.Nonce, []byte(privateKey), additionalData(name, publicKey))
+
+	keyFileBytes, err := json.MarshalIndent(keyFile, "", "  ")
+	if err != nil {
+		return err
+	}
+	temporaryPath := keystore.path(name) + ".tmp"
+	if err := ioutil.WriteFile(temporaryPath, keyFileBytes, 0600); err != nil {
+		return err
+	}
+	return os.Rename(temporaryPath, keystore.path(name))
+}
+
+/* Read the key file stored under a name */
+func (keystore *Keystore) readKeyFile(name string) (KeyFile, error) {
+	var keyFile KeyFile
+	if !validName.MatchString(name) {
+		return keyFile, errors.New("invalid key name " + name)
+	}
+	keyFileBytes, err := ioutil.ReadFile(keystore.path(name))
+	if os.IsNotExist(err) {
+		return keyFile, ErrKeyNotFound
+	} else if err != nil {
+		return keyFile, err
+	}
+	if err := json.Unmarshal(keyFileBytes, &keyFile); err != nil {
+		return keyFile, errors.New("corrupt key file " + keystore.path(name) + ": " + err.Error())
+	}
+	if keyFile.Version != KEY_FILE_VERSION || keyFile.Name != name {
+		return keyFile, errors.New("unsupported key file " + keystore.path(name))
+	}
+	return keyFile, nil
+}
+
+func (keystore *Keystore) path(name string) string {
+	return filepath.Join(keystore.Dir, name+KEY_FILE_EXTENSION)
+}
+
+/* Make the AES-256-GCM cipher keyed with the password */
+func makeAEAD(password string, params KDFParams) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(password), params.Salt, params.N, params.R, params.P, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+/* Data authenticated along with the private key, binding it to its name and public key */
+func additionalData(name string, publicKey string) []byte {
+	return []byte(name + "\n" + publicKey)
+}
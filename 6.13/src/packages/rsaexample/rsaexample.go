@@ -15,13 +15,10 @@ package rsaexample
 import (
 	"crypto/rand"
 	"crypto/sha256"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"log"
 	"math/big"
-	"os"
+	"week_4/src/packages/keystore"
 )
 
 /* Key struct */
@@ -155,58 +152,46 @@ func GenerateRandomK() *big.Int {
 	return k
 }
 
-func Generate(filename string, password string) Key {
-	/* Encrypt filename with the password using an XOR cipher */
-	if len(filename) != len(password) {
-		fmt.Println("Filename and password must be the same size.")
-	}
-	filenameBytes := []byte(filename)
-	passwordBytes := []byte(password)
-	encryptedFilenameBytes := make([]byte, len(filenameBytes))
-	for i := 0; i < len(passwordBytes); i++ {
-		encryptedFilenameBytes[i] = filenameBytes[i] ^ passwordBytes[i]
+/* Generate a key pair and store the private key encrypted with the password under a name in a keystore directory */
+func Generate(keystoreDir string, name string, password string) (Key, error) {
+	store, err := keystore.Open(keystoreDir)
+	if err != nil {
+		return Key{}, err
 	}
-	encryptedFilenameHex := hex.EncodeToString(encryptedFilenameBytes)
 
 	/* Generate public and private key */
 	k := GenerateRandomK()
 	e := 3
 	publicKey, privateKey := KeyGen(k, e)
 
-	/* Save private key in a file with the encrypted filename */
-	err := ioutil.WriteFile(encryptedFilenameHex+".data", privateKey.ToBytes(), 0777)
+	/* Save the encrypted private key in the keystore */
+	err = store.Store(name, password, string(privateKey.ToBytes()), string(publicKey.ToBytes()))
 	if err != nil {
-		fmt.Println(err)
+		return Key{}, err
 	}
 
 	/* Return public key */
-	return publicKey
+	return publicKey, nil
 }
 
-func Sign(filename string, password string, msg []byte) *big.Int {
-	/* Encrypt filename with the password using an XOR cipher */
-	if len(filename) != len(password) {
-		fmt.Println("Filename and password must be the same size.")
-	}
-	filenameBytes := []byte(filename)
-	passwordBytes := []byte(password)
-	encryptedFilenameBytes := make([]byte, len(filenameBytes))
-	for i := 0; i < len(passwordBytes); i++ {
-		encryptedFilenameBytes[i] = filenameBytes[i] ^ passwordBytes[i]
+/* Sign a message with the private key stored under a name in a keystore directory */
+func Sign(keystoreDir string, name string, password string, msg []byte) (*big.Int, error) {
+	store, err := keystore.Open(keystoreDir)
+	if err != nil {
+		return nil, err
 	}
-	encryptedFilenameHex := hex.EncodeToString(encryptedFilenameBytes)
 
-	/* If file with the encrypted filename exists, read private key*/
-	privateKeyBytes, err := os.ReadFile(encryptedFilenameHex + ".data")
+	/* Decrypt the private key with the password */
+	privateKeyString, _, err := store.Load(name, password)
 	if err != nil {
-		log.Fatal("Wrong filename/password.")
+		return nil, err
 	}
-	privateKey := ToKey(privateKeyBytes)
+	privateKey := ToKey([]byte(privateKeyString))
 
 	/* Sign message */
 	msgInt := ByteArrayToInt(msg)
 	signature := Encrypt(msgInt, privateKey)
 
 	/* Return signature */
-	return signature
+	return signature, nil
 }